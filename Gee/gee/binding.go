@@ -0,0 +1,235 @@
+﻿package gee
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 解析 multipart 表单时驻留内存的上限，与 net/http 默认值一致
+const defaultMultipartMemory = 32 << 20
+
+// BindJSON 将请求体按 JSON 解码到 obj
+func (c *Context) BindJSON(obj interface{}) error {
+	if c.Request.Body == nil {
+		return errors.New("gee: empty request body")
+	}
+	return json.NewDecoder(c.Request.Body).Decode(obj)
+}
+
+// Bind 根据 Content-Type 选择解码方式，把请求数据绑定到结构体指针 obj：
+// application/json 按 JSON 解码；表单（urlencoded/multipart）按 form 标签（没有时用 json 标签，再没有用字段名）赋值。
+// 绑定成功后按 binding 标签校验，校验失败返回 ValidationErrors
+func (c *Context) Bind(obj interface{}) error {
+	if err := c.bind(obj); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+func (c *Context) bind(obj interface{}) error {
+	mediaType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		return c.BindJSON(obj)
+	case "application/x-www-form-urlencoded":
+		if err := c.Request.ParseForm(); err != nil {
+			return err
+		}
+		return bindForm(obj, c.Request.PostForm)
+	case "multipart/form-data":
+		if err := c.Request.ParseMultipartForm(defaultMultipartMemory); err != nil {
+			return err
+		}
+		return bindForm(obj, c.Request.MultipartForm.Value)
+	default:
+		return fmt.Errorf("gee: unsupported content type %q", mediaType)
+	}
+}
+
+// bindForm 把表单值按字段标签写入结构体指针 obj，嵌入的结构体会被展开
+func bindForm(obj interface{}, values map[string][]string) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("gee: bind target must be a non-nil pointer to struct")
+	}
+	return bindStruct(v.Elem(), values)
+}
+
+func bindStruct(v reflect.Value, values map[string][]string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := bindStruct(v.Field(i), values); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name := formName(field)
+		if name == "-" {
+			continue
+		}
+		vals, ok := values[name]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		if err := setFormValue(v.Field(i), vals); err != nil {
+			return fmt.Errorf("gee: bind field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// formName 依次取 form 标签、json 标签、字段名作为表单键
+func formName(field reflect.StructField) string {
+	for _, key := range []string{"form", "json"} {
+		if tag, ok := field.Tag.Lookup(key); ok {
+			if name := strings.Split(tag, ",")[0]; name != "" {
+				return name
+			}
+		}
+	}
+	return field.Name
+}
+
+// setFormValue 为字段赋值，切片字段接收全部值，其余字段取第一个值
+func setFormValue(field reflect.Value, vals []string) error {
+	if field.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(field.Type(), len(vals), len(vals))
+		for i, s := range vals {
+			if err := setScalar(slice.Index(i), s); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return setScalar(field, vals[0])
+}
+
+func setScalar(field reflect.Value, s string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// FieldError 描述一个未通过校验的字段
+type FieldError struct {
+	Field string // 结构体字段名
+	Rule  string // 未通过的规则，如 required、min=1
+}
+
+// ValidationErrors 是 Validate 返回的错误，列出所有未通过校验的字段
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fmt.Sprintf("%s failed on %s", fe.Field, fe.Rule))
+	}
+	return "gee: validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Validate 按 binding 标签校验结构体指针 obj 的字段，规则以逗号分隔：
+// required 要求非零值；min=N/max=N 对数字比较数值，对字符串、切片、map 比较长度
+func Validate(obj interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs ValidationErrors
+	validateStruct(v, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, errs *ValidationErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			validateStruct(v.Field(i), errs)
+			continue
+		}
+		tag, ok := field.Tag.Lookup("binding")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		for _, rule := range strings.Split(tag, ",") {
+			rule = strings.TrimSpace(rule)
+			if rule != "" && !checkRule(v.Field(i), rule) {
+				*errs = append(*errs, FieldError{Field: field.Name, Rule: rule})
+			}
+		}
+	}
+}
+
+// checkRule 检查字段是否满足单条规则，无法识别的规则视为通过
+func checkRule(field reflect.Value, rule string) bool {
+	if rule == "required" {
+		return !field.IsZero()
+	}
+	name, arg, ok := strings.Cut(rule, "=")
+	if !ok || (name != "min" && name != "max") {
+		return true
+	}
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return false
+	}
+	var n float64
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(field.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(field.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = field.Float()
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		n = float64(field.Len())
+	default:
+		return true
+	}
+	if name == "min" {
+		return n >= limit
+	}
+	return n <= limit
+}
@@ -0,0 +1,84 @@
+﻿package gee
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type loginForm struct {
+	User     string   `form:"user" json:"user"`
+	Age      int      `json:"age"`
+	Remember bool     `form:"remember" json:"remember"`
+	Tags     []string `form:"tag" json:"tags"`
+}
+
+func TestBind(t *testing.T) {
+	expect := loginForm{User: "geektutu", Age: 18, Remember: true, Tags: []string{"a", "b"}}
+	form := url.Values{"user": {"geektutu"}, "age": {"18"}, "remember": {"true"}, "tag": {"a", "b"}}
+
+	var multipartBody bytes.Buffer
+	mw := multipart.NewWriter(&multipartBody)
+	for key, vals := range form {
+		for _, v := range vals {
+			_ = mw.WriteField(key, v)
+		}
+	}
+	_ = mw.Close()
+
+	tests := []struct {
+		name, contentType, body string
+	}{
+		{"json", "application/json", `{"user":"geektutu","age":18,"remember":true,"tags":["a","b"]}`},
+		{"form", "application/x-www-form-urlencoded", form.Encode()},
+		{"multipart", mw.FormDataContentType(), multipartBody.String()},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		c := newContext(httptest.NewRecorder(), req)
+		var got loginForm
+		if err := c.Bind(&got); err != nil || !reflect.DeepEqual(got, expect) {
+			t.Fatalf("%s: expect %+v, got %+v %v", tt.name, expect, got, err)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/login", strings.NewReader("age=x"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var got loginForm
+	if err := newContext(httptest.NewRecorder(), req).Bind(&got); err == nil || !strings.Contains(err.Error(), "Age") {
+		t.Fatalf("expect bind error naming Age, got %v", err)
+	}
+}
+
+type signupForm struct {
+	User  string `form:"user" binding:"required,min=3"`
+	Email string `form:"email" binding:"required"`
+	Age   int    `form:"age" binding:"min=1,max=150"`
+}
+
+func TestBindValidation(t *testing.T) {
+	bind := func(body string) error {
+		req := httptest.NewRequest("POST", "/signup", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		var form signupForm
+		return newContext(httptest.NewRecorder(), req).Bind(&form)
+	}
+	if err := bind("user=geektutu&email=a@b.c&age=18"); err != nil {
+		t.Fatal("expect valid form, got", err)
+	}
+
+	err := bind("user=ge&age=200")
+	errs, ok := err.(ValidationErrors)
+	expect := ValidationErrors{{"User", "min=3"}, {"Email", "required"}, {"Age", "max=150"}}
+	if !ok || !reflect.DeepEqual(errs, expect) {
+		t.Fatalf("expect %v, got %v", expect, err)
+	}
+	if !strings.Contains(err.Error(), "Email failed on required") {
+		t.Fatalf("validation error should name the missing field, got %q", err.Error())
+	}
+}
@@ -0,0 +1,44 @@
+﻿package gee
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// MaxBodyBytes 限制请求体不超过 n 字节，超出时返回 413，避免把超大请求体读入内存。
+// Content-Length 已声明超限的请求直接拒绝；未声明长度（如分块传输）时，
+// 处理函数读取超限会得到 *http.MaxBytesError，若处理函数随后未写响应则由中间件返回 413
+func MaxBodyBytes(n int64) HandlerFunc {
+	return func(c *Context) {
+		if c.Request.ContentLength > n {
+			c.Fail(http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		body := &limitedBody{ReadCloser: http.MaxBytesReader(c.Writer, c.Request.Body, n)}
+		c.Request.Body = body
+		c.Next()
+		if body.exceeded && c.StatusCode == 0 { // 处理函数读取失败后没有响应
+			c.Fail(http.StatusRequestEntityTooLarge, "request body too large")
+		}
+	}
+}
+
+// limitedBody 记录读取时是否超出长度限制
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		b.exceeded = true
+	}
+	return n, err
+}
@@ -0,0 +1,50 @@
+﻿package gee
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBodyBytes(t *testing.T) {
+	r := New()
+	r.Use(MaxBodyBytes(8))
+	var readErr error
+	r.POST("/upload", func(c *Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if readErr = err; err != nil {
+			return // 不写响应，由中间件返回 413
+		}
+		c.String(http.StatusOK, "%d", len(body))
+	})
+	post := func(body string, chunked bool) *httptest.ResponseRecorder {
+		readErr = nil
+		req := httptest.NewRequest("POST", "/upload", strings.NewReader(body))
+		if chunked {
+			req.ContentLength = -1 // 未声明长度
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post("small", false); w.Code != http.StatusOK || w.Body.String() != "5" {
+		t.Fatalf("expect small body accepted, got %d %q", w.Code, w.Body.String())
+	}
+	// 声明的长度超限，处理函数不会执行
+	if w := post(strings.Repeat("x", 64), false); w.Code != http.StatusRequestEntityTooLarge || readErr != nil {
+		t.Fatalf("expect 413 before handler, got %d %v", w.Code, readErr)
+	}
+	// 未声明长度，读取时超限
+	w := post(strings.Repeat("x", 64), true)
+	var maxErr *http.MaxBytesError
+	if !errors.As(readErr, &maxErr) {
+		t.Fatalf("expect handler read to fail with MaxBytesError, got %v", readErr)
+	}
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expect 413 for oversized chunked body, got %d", w.Code)
+	}
+}
@@ -0,0 +1,19 @@
+﻿package gee
+
+import "net/http"
+
+// MaxConcurrency 限制同时处理的请求数不超过 n，已满时直接返回 503，防止过载；
+// 限制对使用该中间件的所有路由共享
+func MaxConcurrency(n int) HandlerFunc {
+	sem := make(chan struct{}, n) // 信号量，容量即并发上限
+	return func(c *Context) {
+		select {
+		case sem <- struct{}{}:
+		default:
+			c.Fail(http.StatusServiceUnavailable, "server is at capacity")
+			return
+		}
+		defer func() { <-sem }()
+		c.Next()
+	}
+}
@@ -0,0 +1,43 @@
+﻿package gee
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrency(t *testing.T) {
+	r := New()
+	r.Use(MaxConcurrency(2))
+	r.GET("/slow", func(c *Context) {
+		time.Sleep(100 * time.Millisecond)
+		c.String(http.StatusOK, "ok")
+	})
+
+	var wg sync.WaitGroup
+	codes := make([]int, 6)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = serve(r, "GET", "/slow").Code
+		}(i)
+	}
+	wg.Wait()
+	var ok, rejected int
+	for _, code := range codes {
+		switch code {
+		case http.StatusOK:
+			ok++
+		case http.StatusServiceUnavailable:
+			rejected++
+		}
+	}
+	if ok < 1 || ok > 2 || ok+rejected != len(codes) {
+		t.Fatalf("expect at most 2 requests served and the rest rejected, got %v", codes)
+	}
+	if w := serve(r, "GET", "/slow"); w.Code != http.StatusOK {
+		t.Fatal("expect slots released after requests finished, got", w.Code)
+	}
+}
@@ -2,8 +2,12 @@ package gee
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"strings"
 )
 
 type H map[string]interface{}
@@ -16,8 +20,14 @@ type Context struct {
 	Path   string
 	Method string
 	Params map[string]string
+	//匹配到的路由模式，如/hello/:name，未匹配时为空
+	fullPath string
 	//响应信息
 	StatusCode int
+	//处理过程中记录的错误
+	Errors []error
+	//中间件与处理函数之间传递的数据
+	Keys map[string]interface{}
 	//中间件
 	handlers []HandlerFunc
 	index    int
@@ -43,15 +53,51 @@ func (c *Context) Query(key string) string { //获取url参数
 	return c.Request.URL.Query().Get(key)
 }
 
+// ClientIP 返回客户端真实IP
+// 请求直接来自受信任代理时，从右向左解析X-Forwarded-For，跳过受信任代理取第一个地址，
+// 其次使用X-Real-IP；否则直接使用RemoteAddr
+func (c *Context) ClientIP() string {
+	remote, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		remote = strings.TrimSpace(c.Request.RemoteAddr)
+	}
+	remoteIP := net.ParseIP(remote)
+	if c.engine == nil || remoteIP == nil || !c.engine.isTrustedProxy(remoteIP) {
+		return remote
+	}
+	if xff := c.Request.Header.Get("X-Forwarded-For"); xff != "" {
+		ips := strings.Split(xff, ",")
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(ips[i]))
+			if ip == nil {
+				break // 地址不合法，后面的内容不可信
+			}
+			if i == 0 || !c.engine.isTrustedProxy(ip) {
+				return ip.String()
+			}
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(c.Request.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return remote
+}
+
+// Status 只写入状态码，不写响应体，适合 204、304 等无响应体的场景；
+// 需要设置的响应头应在调用前通过 SetHeader 设置
 func (c *Context) Status(code int) {
 	c.StatusCode = code
 	c.Writer.WriteHeader(code)
 }
 
-func (c *Context) SetHeader(key, value string) {
+func (c *Context) SetHeader(key, value string) { //设置响应头
 	c.Writer.Header().Set(key, value)
 }
 
+func (c *Context) GetHeader(key string) string { //获取请求头
+	return c.Request.Header.Get(key)
+}
+
 func (c *Context) String(code int, format string, values ...interface{}) {
 	c.SetHeader("Content-Type", "text/plain; charset=utf-8")
 	c.Status(code)
@@ -67,15 +113,125 @@ func (c *Context) JSON(code int, obj interface{}) {
 	}
 }
 
+func (c *Context) IndentedJSON(code int, obj interface{}) { //带缩进的JSON，便于开发时阅读
+	data, err := json.MarshalIndent(obj, "", "    ")
+	if err != nil {
+		http.Error(c.Writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	c.SetHeader("Content-Type", "application/json; charset=utf-8")
+	c.Status(code)
+	c.Writer.Write(data)
+}
+
+func (c *Context) PureJSON(code int, obj interface{}) { //不对<、>、&做HTML转义的JSON
+	c.SetHeader("Content-Type", "application/json; charset=utf-8")
+	c.Status(code)
+	encoder := json.NewEncoder(c.Writer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(obj); err != nil {
+		http.Error(c.Writer, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (c *Context) XML(code int, obj interface{}) {
+	c.SetHeader("Content-Type", "application/xml; charset=utf-8")
+	c.Status(code)
+	encoder := xml.NewEncoder(c.Writer)
+	if err := encoder.Encode(obj); err != nil {
+		http.Error(c.Writer, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Negotiate 根据请求头 Accept 选择 JSON、XML 或纯文本格式渲染，
+// 按 Accept 中出现的顺序取第一个支持的类型，Accept 为空或 */* 时默认 JSON
+func (c *Context) Negotiate(code int, data interface{}) {
+	for _, accept := range strings.Split(c.Request.Header.Get("Accept"), ",") {
+		mediaType := strings.TrimSpace(strings.Split(accept, ";")[0]) // 去掉 q 等参数
+		switch mediaType {
+		case "application/json", "*/*", "":
+			c.JSON(code, data)
+			return
+		case "application/xml", "text/xml":
+			c.XML(code, data)
+			return
+		case "text/plain":
+			c.String(code, "%v", data)
+			return
+		}
+	}
+	c.JSON(code, data)
+}
+
 func (c *Context) Data(code int, data []byte) {
 	c.Status(code)
 	c.Writer.Write(data)
 }
 
+// Stream 循环调用 step 写入响应并立即刷新，直到 step 返回 false 或客户端断开连接，
+// 返回值表示客户端是否已断开
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	done := c.Request.Context().Done()
+	flusher, _ := c.Writer.(http.Flusher)
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+		}
+		keepOpen := step(c.Writer)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if !keepOpen {
+			return false
+		}
+	}
+}
+
+// SSEvent 写入一条 server-sent event，name 为空时省略 event 行；
+// data 为字符串时原样写入，否则编码为 JSON，多行数据拆分为多个 data 行
+func (c *Context) SSEvent(name string, data interface{}) {
+	header := c.Writer.Header()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Connection", "keep-alive")
+	}
+	var payload string
+	switch v := data.(type) {
+	case string:
+		payload = v
+	case []byte:
+		payload = string(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		payload = string(b)
+	}
+	var frame strings.Builder
+	if name != "" {
+		frame.WriteString("event: " + name + "\n")
+	}
+	for _, line := range strings.Split(payload, "\n") {
+		frame.WriteString("data: " + line + "\n")
+	}
+	frame.WriteString("\n")
+	io.WriteString(c.Writer, frame.String())
+}
+
 func (c *Context) HTML(code int, name string, data interface{}) {
+	templates, err := c.engine.templates()
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, err.Error())
+		return
+	}
 	c.SetHeader("Content-Type", "text/html; charset=utf-8")
 	c.Status(code)
-	if err := c.engine.htmlTemplates.ExecuteTemplate(c.Writer, name, data); err != nil {
+	if err := templates.ExecuteTemplate(c.Writer, name, data); err != nil {
 		c.Fail(http.StatusInternalServerError, err.Error())
 	}
 }
@@ -85,6 +241,12 @@ func (c *Context) Param(key string) string {
 	return value
 }
 
+// FullPath 返回匹配到的路由模式（如/users/:id），未匹配任何路由时返回空字符串；
+// 适合作为监控指标、日志的标签，避免具体路径带来的高基数
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
 func (c *Context) Next() {
 	c.index++
 	s := len(c.handlers)
@@ -97,3 +259,53 @@ func (c *Context) Fail(code int, err string) {
 	c.index = len(c.handlers) // 跳过后续中间件
 	c.JSON(code, H{"message": err})
 }
+
+// Set 保存键值对，供后续中间件或处理函数通过 Get 读取
+func (c *Context) Set(key string, value interface{}) {
+	if c.Keys == nil {
+		c.Keys = make(map[string]interface{})
+	}
+	c.Keys[key] = value
+}
+
+// Get 读取 Set 保存的值，exists 表示键是否存在
+func (c *Context) Get(key string) (value interface{}, exists bool) {
+	value, exists = c.Keys[key]
+	return
+}
+
+// MustGet 读取 Set 保存的值，键不存在时 panic
+func (c *Context) MustGet(key string) interface{} {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic("gee: key \"" + key + "\" does not exist")
+}
+
+// GetString 读取字符串类型的值，键不存在或类型不符时返回 "", false
+func (c *Context) GetString(key string) (s string, ok bool) {
+	if value, exists := c.Get(key); exists {
+		s, ok = value.(string)
+	}
+	return
+}
+
+// GetInt 读取 int 类型的值，键不存在或类型不符时返回 0, false
+func (c *Context) GetInt(key string) (i int, ok bool) {
+	if value, exists := c.Get(key); exists {
+		i, ok = value.(int)
+	}
+	return
+}
+
+// GetBool 读取 bool 类型的值，键不存在或类型不符时返回 false, false
+func (c *Context) GetBool(key string) (b bool, ok bool) {
+	if value, exists := c.Get(key); exists {
+		b, ok = value.(bool)
+	}
+	return
+}
+
+func (c *Context) Error(err error) { //记录错误，供后续的错误处理中间件统一读取
+	c.Errors = append(c.Errors, err)
+}
@@ -1,6 +1,14 @@
 ﻿package gee
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
 
 func newTestContext() *Context {
 	return &Context{}
@@ -14,12 +22,63 @@ func TestQuery(t *testing.T) {
 
 }
 
-func TestStatus(t *testing.T) {
+func TestClientIP(t *testing.T) {
+	e := New()
+	if err := e.SetTrustedProxies([]string{"10.0.0.1", "192.168.0.0/16"}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, remote, xff, realIP, expect string
+	}{
+		{"direct", "1.2.3.4:1234", "", "", "1.2.3.4"},
+		{"trusted proxy", "10.0.0.1:80", "5.6.7.8, 192.168.1.2", "", "5.6.7.8"},
+		{"trusted proxy with real ip", "10.0.0.1:80", "", "5.6.7.8", "5.6.7.8"},
+		{"untrusted proxy", "9.9.9.9:80", "5.6.7.8", "5.6.7.8", "9.9.9.9"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tt.remote
+		if tt.xff != "" {
+			req.Header.Set("X-Forwarded-For", tt.xff)
+		}
+		if tt.realIP != "" {
+			req.Header.Set("X-Real-IP", tt.realIP)
+		}
+		c := newContext(httptest.NewRecorder(), req)
+		c.engine = e
+		if ip := c.ClientIP(); ip != tt.expect {
+			t.Fatalf("%s: expect %s, got %s", tt.name, tt.expect, ip)
+		}
+	}
+	if err := e.SetTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Fatal("expect invalid proxy error")
+	}
+}
 
+func TestStatus(t *testing.T) {
+	r := New()
+	r.GET("/empty", func(c *Context) {
+		c.Status(http.StatusNoContent)
+	})
+	w := serve(r, "GET", "/empty")
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Fatalf("expect 204 with empty body, got %d %q", w.Code, w.Body.String())
+	}
 }
 
 func TestSetHeader(t *testing.T) {
-
+	r := New()
+	r.GET("/header", func(c *Context) {
+		c.SetHeader("X-Echo", c.GetHeader("X-Request"))
+		c.String(http.StatusOK, "ok")
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/header", nil)
+	req.Header.Set("X-Request", "gee")
+	r.ServeHTTP(w, req)
+	if got := w.Header().Get("X-Echo"); got != "gee" {
+		t.Fatalf("expect header X-Echo=gee, got %q", got)
+	}
 }
 
 func TestString(t *testing.T) {
@@ -30,6 +89,30 @@ func TestJSON(t *testing.T) {
 
 }
 
+func TestIndentedJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newContext(w, httptest.NewRequest("GET", "/", nil))
+	c.IndentedJSON(http.StatusOK, H{"name": "gee"})
+	if w.Body.String() != "{\n    \"name\": \"gee\"\n}" {
+		t.Fatalf("unexpected indented json: %q", w.Body.String())
+	}
+}
+
+func TestPureJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newContext(w, httptest.NewRequest("GET", "/", nil))
+	c.PureJSON(http.StatusOK, H{"html": "<b>&</b>"})
+	if w.Body.String() != "{\"html\":\"<b>&</b>\"}\n" {
+		t.Fatalf("unexpected pure json: %q", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	c = newContext(w, httptest.NewRequest("GET", "/", nil))
+	c.JSON(http.StatusOK, H{"html": "<b>&</b>"})
+	if strings.Contains(w.Body.String(), "<b>") {
+		t.Fatal("JSON should still escape html")
+	}
+}
+
 func TestData(t *testing.T) {
 
 }
@@ -49,3 +132,127 @@ func TestNext(t *testing.T) {
 func TestFail(t *testing.T) {
 
 }
+
+func TestError(t *testing.T) {
+	r := New()
+	r.Use(func(c *Context) { // 错误处理中间件：等后续处理完成后统一输出错误
+		c.Next()
+		if len(c.Errors) > 0 {
+			msgs := make([]string, 0, len(c.Errors))
+			for _, err := range c.Errors {
+				msgs = append(msgs, err.Error())
+			}
+			c.String(http.StatusInternalServerError, "%s", strings.Join(msgs, "; "))
+		}
+	})
+	r.Use(func(c *Context) {
+		c.Error(errors.New("auth failed"))
+		c.Next()
+	})
+	r.GET("/", func(c *Context) {
+		c.Error(errors.New("handler failed"))
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "auth failed; handler failed" {
+		t.Fatalf("unexpected response: %d %q", w.Code, w.Body.String())
+	}
+}
+
+type negotiateData struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func TestNegotiate(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) {
+		c.Negotiate(http.StatusOK, negotiateData{Name: "gee"})
+	})
+	tests := []struct {
+		accept, contentType, body string
+	}{
+		{"application/json", "application/json; charset=utf-8", "{\"name\":\"gee\"}\n"},
+		{"text/html, application/xml;q=0.9", "application/xml; charset=utf-8", "<negotiateData><name>gee</name></negotiateData>"},
+		{"text/plain", "text/plain; charset=utf-8", "{gee}"},
+		{"*/*", "application/json; charset=utf-8", "{\"name\":\"gee\"}\n"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Header().Get("Content-Type") != tt.contentType || w.Body.String() != tt.body {
+			t.Fatalf("Accept %q: unexpected response %q %q", tt.accept, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	r := New()
+	r.GET("/events", func(c *Context) {
+		i := 0
+		c.Stream(func(w io.Writer) bool {
+			i++
+			c.SSEvent("tick", H{"n": i})
+			return i < 3
+		})
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/events", nil))
+	if w.Header().Get("Content-Type") != "text/event-stream" || !w.Flushed {
+		t.Fatalf("unexpected stream response %q flushed=%v", w.Header().Get("Content-Type"), w.Flushed)
+	}
+	expect := "event: tick\ndata: {\"n\":1}\n\nevent: tick\ndata: {\"n\":2}\n\nevent: tick\ndata: {\"n\":3}\n\n"
+	if w.Body.String() != expect {
+		t.Fatalf("unexpected events %q", w.Body.String())
+	}
+}
+
+func TestStream_ClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := newContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	if !c.Stream(func(w io.Writer) bool { t.Fatal("step should not run"); return true }) {
+		t.Fatal("expect Stream to report client gone")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	c := newContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if _, ok := c.Get("user"); ok {
+		t.Fatal("expect missing key on a new context")
+	}
+	c.Set("user", "geektutu")
+	c.Set("age", 18)
+	c.Set("admin", true)
+
+	if v := c.MustGet("user"); v != "geektutu" {
+		t.Fatalf("unexpected MustGet value %v", v)
+	}
+	if s, ok := c.GetString("user"); !ok || s != "geektutu" {
+		t.Fatalf("GetString = %q, %v", s, ok)
+	}
+	if i, ok := c.GetInt("age"); !ok || i != 18 {
+		t.Fatalf("GetInt = %d, %v", i, ok)
+	}
+	if b, ok := c.GetBool("admin"); !ok || !b {
+		t.Fatalf("GetBool = %v, %v", b, ok)
+	}
+	// 类型不符与键不存在都返回零值
+	if s, ok := c.GetString("age"); ok || s != "" {
+		t.Fatalf("expect type mismatch, got %q, %v", s, ok)
+	}
+	if i, ok := c.GetInt("missing"); ok || i != 0 {
+		t.Fatalf("expect missing key, got %d, %v", i, ok)
+	}
+	if b, ok := c.GetBool("user"); ok || b {
+		t.Fatalf("expect type mismatch, got %v, %v", b, ok)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expect MustGet to panic on missing key")
+		}
+	}()
+	c.MustGet("missing")
+}
@@ -1,8 +1,10 @@
 ﻿package gee
 
 import (
+	"fmt"
 	"html/template"
 	"log"
+	"net"
 	"net/http"
 	"path"
 	"strings"
@@ -13,11 +15,14 @@ type HandlerFunc func(*Context)
 
 // Engine实现了ServeHTTP接口
 type Engine struct {
-	router        *router
-	*RouterGroup                     // 顶级路由组
-	groups        []*RouterGroup     // 路由组列表
-	htmlTemplates *template.Template // HTML模板
-	funcMap       template.FuncMap   // 模板函数映射
+	router         *router
+	*RouterGroup                      // 顶级路由组
+	groups         []*RouterGroup     // 路由组列表
+	htmlTemplates  *template.Template // HTML模板
+	funcMap        template.FuncMap   // 模板函数映射
+	trustedProxies []*net.IPNet       // 受信任的代理网段，来自它们的转发头才会被采信
+	htmlPattern    string             // LoadHTMLGlob使用的通配符路径
+	devMode        bool               // 开发模式下每次渲染都重新解析模板
 }
 
 type RouterGroup struct {
@@ -41,22 +46,41 @@ func Default() *Engine {
 	return engine
 }
 
-func (e *Engine) addRoute(method string, pattern string, handler HandlerFunc) {
-	e.router.addRoute(method, pattern, handler)
-}
-
-// GET方法注册路由
-func (e *Engine) GET(pattern string, handlers ...HandlerFunc) {
-	for _, handler := range handlers {
-		e.addRoute("GET", pattern, handler)
+// SetTrustedProxies设置受信任的代理，支持单个IP或CIDR网段
+// 只有请求直接来自这些代理时，ClientIP才会采信X-Forwarded-For/X-Real-IP；传nil则不信任任何代理
+func (e *Engine) SetTrustedProxies(proxies []string) error {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") { // 单个IP转换为掩码全满的网段
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("gee: invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("gee: invalid trusted proxy %q: %v", proxy, err)
+		}
+		nets = append(nets, ipNet)
 	}
+	e.trustedProxies = nets
+	return nil
 }
 
-// POST方法注册路由
-func (e *Engine) POST(pattern string, handlers ...HandlerFunc) {
-	for _, handler := range handlers {
-		e.addRoute("POST", pattern, handler)
+// isTrustedProxy判断ip是否属于受信任的代理
+func (e *Engine) isTrustedProxy(ip net.IP) bool {
+	for _, ipNet := range e.trustedProxies {
+		if ipNet.Contains(ip) {
+			return true
+		}
 	}
+	return false
 }
 
 // Run启动HTTP服务
@@ -77,6 +101,11 @@ func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	e.router.handle(c) // router.handle会调用c.Next()
 }
 
+// Routes 返回所有已注册的路由，按路由模式、请求方法排序
+func (e *Engine) Routes() []RouteInfo {
+	return e.router.routes()
+}
+
 func (g *RouterGroup) Group(prefix string) *RouterGroup {
 	engine := g.engine
 	newGroup := &RouterGroup{
@@ -88,22 +117,26 @@ func (g *RouterGroup) Group(prefix string) *RouterGroup {
 	return newGroup
 }
 
-func (g *RouterGroup) addRoute(method string, comp string, handler HandlerFunc) {
+// Mount创建子路由组并注册中间件，再由register统一注册该模块的路由
+func (g *RouterGroup) Mount(prefix string, middlewares []HandlerFunc, register func(g *RouterGroup)) *RouterGroup {
+	group := g.Group(prefix)
+	group.Use(middlewares...)
+	register(group)
+	return group
+}
+
+func (g *RouterGroup) addRoute(method string, comp string, handlers ...HandlerFunc) {
 	pattern := g.prefix + comp // 组合路径
 	log.Printf("Router %4s - %s", method, pattern)
-	g.engine.router.addRoute(method, pattern, handler)
+	g.engine.router.addRoute(method, pattern, handlers...)
 }
 
 func (g *RouterGroup) GET(pattern string, handlers ...HandlerFunc) {
-	for _, handler := range handlers {
-		g.addRoute("GET", pattern, handler)
-	}
+	g.addRoute("GET", pattern, handlers...)
 }
 
 func (g *RouterGroup) POST(pattern string, handlers ...HandlerFunc) {
-	for _, handler := range handlers {
-		g.addRoute("POST", pattern, handler)
-	}
+	g.addRoute("POST", pattern, handlers...)
 }
 
 func (g *RouterGroup) Use(middlewares ...HandlerFunc) { // 注册中间件
@@ -114,15 +147,49 @@ func (g *RouterGroup) createStaticHandler(relativePath string, fs http.FileSyste
 	absolutePath := path.Join(g.prefix, relativePath)
 	fileServer := http.StripPrefix(absolutePath, http.FileServer(fs))
 	return func(c *Context) {
-		file := c.Param("filepath")
-		if _, err := fs.Open(file); err != nil { // 判断文件是否存在
+		file, ok := cleanStaticPath(c.Param("filepath"))
+		if !ok { // 可疑路径按不存在处理，不暴露任何信息
+			c.Status(http.StatusNotFound)
+			return
+		}
+		f, err := fs.Open(file) // 判断文件是否存在
+		if err != nil {
 			c.Status(http.StatusNotFound)
 			return
 		}
+		_ = f.Close()
 		fileServer.ServeHTTP(c.Writer, c.Request)
 	}
 }
 
+// cleanStaticPath 校验并规范化*filepath参数，返回以"/"开头的路径
+// 含".."分片（"/"或"\"分隔）、盘符前缀（如"C:"）或NUL的路径可能逃出静态根目录，一律拒绝，
+// 不依赖具体http.FileSystem实现自身的防护；文件名中的普通冒号不受影响
+func cleanStaticPath(file string) (string, bool) {
+	if strings.ContainsRune(file, 0) {
+		return "", false
+	}
+	parts := strings.FieldsFunc(file, func(r rune) bool { return r == '/' || r == '\\' })
+	if len(parts) > 0 && hasDrivePrefix(parts[0]) {
+		return "", false
+	}
+	for _, part := range parts {
+		if part == ".." {
+			return "", false
+		}
+	}
+	return path.Clean("/" + strings.Join(parts, "/")), true
+}
+
+// hasDrivePrefix 判断分片是否以Windows盘符开头，即^[A-Za-z]:
+func hasDrivePrefix(s string) bool {
+	if len(s) < 2 || s[1] != ':' {
+		return false
+	}
+	c := s[0]
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
 // Static注册静态文件服务
 func (g *RouterGroup) Static(relativePath string, root string) {
 	handler := g.createStaticHandler(relativePath, http.Dir(root))
@@ -141,5 +208,20 @@ func (e *Engine) SetFuncMap(funcMap template.FuncMap) {
 // .ParseGlob(pattern)解析匹配的所有模板文件
 // template.Must()确保解析错误时触发panic（安全启动）
 func (e *Engine) LoadHTMLGlob(pattern string) {
+	e.htmlPattern = pattern
 	e.htmlTemplates = template.Must(template.New("").Funcs(e.funcMap).ParseGlob(pattern))
 }
+
+// SetDevMode开启开发模式后，Context.HTML每次渲染前都会重新解析模板，修改模板无需重启
+// 默认关闭，模板只在LoadHTMLGlob时解析一次
+func (e *Engine) SetDevMode(on bool) {
+	e.devMode = on
+}
+
+// templates返回用于渲染的模板集，开发模式下重新解析
+func (e *Engine) templates() (*template.Template, error) {
+	if !e.devMode || e.htmlPattern == "" {
+		return e.htmlTemplates, nil
+	}
+	return template.New("").Funcs(e.funcMap).ParseGlob(e.htmlPattern)
+}
@@ -0,0 +1,192 @@
+﻿package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// serve 发起一次请求并返回响应记录
+func serve(e *Engine, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestMount(t *testing.T) {
+	r := New()
+	var calls int
+	auth := func(c *Context) {
+		calls++
+		c.Next()
+	}
+	r.Mount("/api", []HandlerFunc{auth}, func(g *RouterGroup) {
+		g.GET("/users", func(c *Context) {
+			c.String(http.StatusOK, "users")
+		})
+		g.POST("/users", func(c *Context) {
+			c.String(http.StatusCreated, "created")
+		})
+	})
+	r.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "index")
+	})
+	if w := serve(r, "GET", "/api/users"); w.Body.String() != "users" {
+		t.Fatalf("unexpected response: %q", w.Body.String())
+	}
+	if w := serve(r, "POST", "/api/users"); w.Code != http.StatusCreated {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+	if w := serve(r, "GET", "/"); w.Body.String() != "index" || calls != 2 {
+		t.Fatalf("mounted middleware should only run for the module, calls=%d", calls)
+	}
+}
+
+func TestRouteMiddleware(t *testing.T) {
+	r := New()
+	var trace []string
+	mw := func(c *Context) {
+		trace = append(trace, "mw")
+		c.Next()
+	}
+	r.GET("/x", mw, func(c *Context) {
+		trace = append(trace, "x")
+	})
+	r.GET("/y", func(c *Context) {
+		trace = append(trace, "y")
+	})
+	serve(r, "GET", "/x")
+	serve(r, "GET", "/y")
+	if strings.Join(trace, ",") != "mw,x,y" {
+		t.Fatalf("route middleware should only run for /x, got %v", trace)
+	}
+}
+
+func TestMultipleHandlers(t *testing.T) {
+	r := New()
+	var trace []string
+	first := func(c *Context) {
+		trace = append(trace, "first")
+	}
+	second := func(c *Context) {
+		trace = append(trace, "second")
+		c.String(http.StatusOK, "done")
+	}
+	r.GET("/", first, second)
+	r.POST("/", second) // 其他方法的同一路径互不影响
+	if w := serve(r, "GET", "/"); w.Body.String() != "done" || strings.Join(trace, ",") != "first,second" {
+		t.Fatalf("both handlers should run in order, got %v", trace)
+	}
+}
+
+func TestDevModeReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "index.tmpl")
+	if err := os.WriteFile(file, []byte(`{{define "index"}}v1{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := New()
+	r.LoadHTMLGlob(filepath.Join(dir, "*"))
+	r.GET("/", func(c *Context) {
+		c.HTML(http.StatusOK, "index", nil)
+	})
+	if w := serve(r, "GET", "/"); w.Body.String() != "v1" {
+		t.Fatalf("unexpected response: %q", w.Body.String())
+	}
+	if err := os.WriteFile(file, []byte(`{{define "index"}}v2{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(r, "GET", "/"); w.Body.String() != "v1" {
+		t.Fatalf("templates should be parsed once by default, got %q", w.Body.String())
+	}
+	r.SetDevMode(true)
+	if w := serve(r, "GET", "/"); w.Body.String() != "v2" {
+		t.Fatalf("templates should be reloaded in dev mode, got %q", w.Body.String())
+	}
+}
+
+func listUsers(c *Context)  {}
+func createUser(c *Context) {}
+
+func TestRoutes(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) {})
+	v1 := r.Group("/v1")
+	v1.GET("/users", listUsers)
+	v1.POST("/users", func(c *Context) {}, createUser) // 路由中间件不计入处理函数名
+	v1.GET("/users/:id", listUsers)
+
+	const pkg = "github.com/nukecoke1828/7daysProgram/Gee/gee."
+	want := []RouteInfo{
+		{Method: "GET", Pattern: "/", HandlerName: pkg + "TestRoutes.func1"},
+		{Method: "GET", Pattern: "/v1/users", HandlerName: pkg + "listUsers"},
+		{Method: "POST", Pattern: "/v1/users", HandlerName: pkg + "createUser"},
+		{Method: "GET", Pattern: "/v1/users/:id", HandlerName: pkg + "listUsers"},
+	}
+	if got := r.Routes(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected routes:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+// recordFS 记录所有被打开的路径
+type recordFS struct {
+	http.FileSystem
+	opened []string
+}
+
+func (fs *recordFS) Open(name string) (http.File, error) {
+	fs.opened = append(fs.opened, name)
+	return fs.FileSystem.Open(name)
+}
+
+func TestStaticPathTraversal(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "static")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "app.js"), []byte("app"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "2024-01-01T10:00.log"), []byte("log"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(parent, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fs := &recordFS{FileSystem: http.Dir(root)}
+	r := New()
+	r.GET("/assets/*filepath", r.createStaticHandler("/assets", fs))
+
+	if w := serve(r, "GET", "/assets/app.js"); w.Code != http.StatusOK || w.Body.String() != "app" {
+		t.Fatalf("expect static file served, got %d %q", w.Code, w.Body.String())
+	}
+	// 文件名中的普通冒号不是盘符，应正常访问
+	if w := serve(r, "GET", "/assets/2024-01-01T10:00.log"); w.Code != http.StatusOK || w.Body.String() != "log" {
+		t.Fatalf("expect file with colon served, got %d %q", w.Code, w.Body.String())
+	}
+	for _, p := range []string{
+		"/assets/../secret.txt",
+		"/assets/../../etc/passwd",
+		"/assets/..%2fsecret.txt",
+		"/assets/..%5csecret.txt",
+		"/assets/js/%2e%2e/%2e%2e/secret.txt",
+		"/assets/C:%5cWindows%5cwin.ini",
+		"/assets/c:/Windows/win.ini",
+		"/assets/%5cD:%5csecret.txt",
+		"/assets/a%00.js",
+	} {
+		if w := serve(r, "GET", p); w.Code != http.StatusNotFound || strings.Contains(w.Body.String(), "secret") {
+			t.Fatalf("%s: expect 404, got %d %q", p, w.Code, w.Body.String())
+		}
+	}
+	for _, name := range fs.opened {
+		if strings.Contains(name, "..") {
+			t.Fatalf("file system should never see %q", name)
+		}
+	}
+}
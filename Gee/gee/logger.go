@@ -13,6 +13,15 @@ func Logger() HandlerFunc {
 
 		ctx.Next()
 
+		if id, ok := ctx.GetString(RequestIDKey); ok { // 使用了 RequestID 中间件时附带请求ID
+			log.Printf("[%s] %s | Status: %d | Time: %v | RequestID: %s",
+				method,
+				path,
+				ctx.StatusCode,
+				time.Since(start),
+				id)
+			return
+		}
 		log.Printf("[%s] %s | Status: %d | Time: %v",
 			method,
 			path,
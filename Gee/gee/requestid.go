@@ -0,0 +1,34 @@
+﻿package gee
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID" // 请求ID使用的请求头与响应头
+	RequestIDKey    = "requestID"    // 请求ID在 Context 中的键
+)
+
+// RequestID 为每个请求分配请求ID：优先沿用请求头 X-Request-ID，否则生成新的ID，
+// 通过 Set 保存到 Context 并写回响应头，便于跨服务追踪
+func RequestID() HandlerFunc {
+	return func(c *Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		c.Set(RequestIDKey, id)
+		c.SetHeader(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// newRequestID 生成 UUID v4 格式的随机ID
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // 版本 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 变体
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
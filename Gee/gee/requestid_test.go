@@ -0,0 +1,54 @@
+﻿package gee
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	r := New()
+	r.Use(RequestID())
+	var seen string
+	r.GET("/", func(c *Context) {
+		seen, _ = c.GetString(RequestIDKey)
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if seen != "abc-123" || w.Header().Get(RequestIDHeader) != "abc-123" {
+		t.Fatalf("expect incoming id propagated, got %q %q", seen, w.Header().Get(RequestIDHeader))
+	}
+
+	w = serve(r, "GET", "/")
+	id := w.Header().Get(RequestIDHeader)
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) || seen != id {
+		t.Fatalf("expect generated uuid, got %q %q", id, seen)
+	}
+	if other := serve(r, "GET", "/").Header().Get(RequestIDHeader); other == id {
+		t.Fatal("expect a new id for each request")
+	}
+}
+
+func TestLogger_RequestID(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	r := New()
+	r.Use(Logger(), RequestID())
+	r.GET("/", func(c *Context) {})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if !strings.Contains(buf.String(), "RequestID: abc-123") {
+		t.Fatalf("expect request id in log, got %q", buf.String())
+	}
+}
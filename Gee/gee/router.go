@@ -3,6 +3,10 @@
 import (
 	"log"
 	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
 )
 
@@ -18,26 +22,74 @@ func newRouter() *router {
 	}
 }
 
-func (r *router) addRoute(method, pattern string, handler HandlerFunc) {
-	log.Printf("Router %4s - %s", method, pattern)
+// handlers中最后一个为路由处理函数，其余为仅作用于该路由的中间件，按顺序执行
+// 同一路由只保存一条处理链，重复注册时后者替换前者
+func (r *router) addRoute(method, pattern string, handlers ...HandlerFunc) {
+	if len(handlers) == 0 {
+		panic("gee: route " + method + " " + pattern + " must have at least one handler")
+	}
 	parts := parsePattern(pattern)
+	regexes := make([]*regexp.Regexp, len(parts)) // 注册时编译参数约束，出错时指出是哪条路由
+	for i, part := range parts {
+		regex, err := compileParam(part)
+		if err != nil {
+			panic("gee: route " + method + " " + pattern + " has an invalid param constraint: " + err.Error())
+		}
+		regexes[i] = regex
+	}
+	log.Printf("Router %4s - %s", method, pattern)
 	key := method + "-" + pattern
 	_, ok := r.roots[method]
 	if !ok {
 		r.roots[method] = &node{}
 	}
-	r.roots[method].insert(pattern, parts, 0)
-	r.handlers[key] = append(r.handlers[key], handler)
+	r.roots[method].insert(pattern, parts, regexes, 0)
+	r.handlers[key] = append([]HandlerFunc(nil), handlers...) // 复制一份，避免调用方复用切片时相互影响
+}
+
+// RouteInfo 描述一条已注册的路由，用于生成接口文档或调试
+type RouteInfo struct {
+	Method      string // 请求方法，如 GET
+	Pattern     string // 完整路由模式，如 /v1/hello/:name
+	HandlerName string // 路由处理函数（处理链的最后一个）的函数名
+}
+
+// routes 按路由模式、请求方法排序返回所有已注册的路由
+func (r *router) routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(r.handlers))
+	for key, handlers := range r.handlers {
+		i := strings.Index(key, "-") // 请求方法中不含"-"，第一个"-"即分隔符
+		routes = append(routes, RouteInfo{
+			Method:      key[:i],
+			Pattern:     key[i+1:],
+			HandlerName: nameOfFunction(handlers[len(handlers)-1]),
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// nameOfFunction 返回函数的完整名称，如 main.helloHandler
+func nameOfFunction(f interface{}) string {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	return runtime.FuncForPC(v.Pointer()).Name()
 }
 
 func (r *router) handle(c *Context) {
 	n, params := r.getRoute(c.Method, c.Path) //路由匹配
 	if n != nil {
 		c.Params = params
+		c.fullPath = n.pattern
 		key := c.Method + "-" + n.pattern
-		for _, v := range r.handlers[key] { //添加路由处理函数
-			c.handlers = append(c.handlers, v)
-		}
+		c.handlers = append(c.handlers, r.handlers[key]...) //添加路由中间件和处理函数
 	} else {
 		c.handlers = append(c.handlers, func(c *Context) {
 			c.String(http.StatusNotFound, "404 page not found: %s\n", c.Path)
@@ -73,7 +125,8 @@ func (r *router) getRoute(method, path string) (*node, map[string]string) {
 		parts := parsePattern(node.pattern)
 		for index, part := range parts {
 			if part[0] == ':' {
-				params[part[1:]] = searchParts[index]
+				name, _ := parseParam(part)
+				params[name] = searchParts[index]
 			}
 			if part[0] == '*' && len(part) > 1 {
 				params[part[1:]] = strings.Join(searchParts[index:], "/")
@@ -3,6 +3,7 @@
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -44,3 +45,78 @@ func TestGetRoute(t *testing.T) {
 	fmt.Printf("matched path: %s, params['name']: %s\n", n.pattern, ps["name"])
 
 }
+
+func TestGetRouteWithConstraint(t *testing.T) {
+	r := newRouter()
+	r.addRoute("GET", "/user/:id(\\d+)", nil)
+	r.addRoute("GET", "/user/:name", nil)
+	r.addRoute("GET", "/post/:id([0-9]+)", nil)
+
+	n, ps := r.getRoute("GET", "/user/123")
+	if n == nil || n.pattern != "/user/:id(\\d+)" || ps["id"] != "123" {
+		t.Fatal("/user/123 should match /user/:id(\\d+)")
+	}
+	n, ps = r.getRoute("GET", "/user/abc")
+	if n == nil || n.pattern != "/user/:name" || ps["name"] != "abc" {
+		t.Fatal("/user/abc should fall through to /user/:name")
+	}
+	if n, _ = r.getRoute("GET", "/post/abc"); n != nil {
+		t.Fatal("/post/abc shouldn't match /post/:id([0-9]+)")
+	}
+}
+
+func TestInvalidConstraintPanics(t *testing.T) {
+	defer func() {
+		r := recover()
+		msg, _ := r.(string)
+		if !strings.Contains(msg, "GET /user/:id([0-9") || !strings.Contains(msg, "invalid param constraint") {
+			t.Fatalf("expect panic naming the route, got %v", r)
+		}
+	}()
+	newRouter().addRoute("GET", "/user/:id([0-9)", nil)
+}
+
+// 无约束的参数节点与插入时相同：同一位置的 :name 与 :id 合并为一个节点，后注册的模式生效，
+// 其后注册的静态分片也并入该节点
+func TestGetRoutePlainParamsMerge(t *testing.T) {
+	r := newRouter()
+	r.addRoute("GET", "/hello/:name", nil)
+	r.addRoute("GET", "/hello/:id", nil)
+	r.addRoute("GET", "/hello/b/c", nil)
+	if len(r.roots["GET"].children) != 1 || len(r.roots["GET"].children[0].children) != 1 {
+		t.Fatal("expect plain params and later static parts merged into one node")
+	}
+	n, ps := r.getRoute("GET", "/hello/tom")
+	if n == nil || n.pattern != "/hello/:id" || ps["id"] != "tom" {
+		t.Fatal("/hello/tom should match the merged /hello/:id, got", n, ps)
+	}
+	n, ps = r.getRoute("GET", "/hello/b/c")
+	if n == nil || n.pattern != "/hello/b/c" {
+		t.Fatal("/hello/b/c should match, got", n, ps)
+	}
+
+	// 带约束的参数节点不与无约束的节点合并
+	r.addRoute("GET", "/hello/:n(\\d+)", nil)
+	if len(r.roots["GET"].children[0].children) != 2 {
+		t.Fatal("expect constrained param in its own node")
+	}
+}
+
+func TestFullPath(t *testing.T) {
+	r := New()
+	var seen []string
+	r.Use(func(c *Context) { // 中间件在处理函数之前即可读取
+		seen = append(seen, c.FullPath())
+		c.Next()
+	})
+	r.GET("/hello/:name", func(c *Context) {
+		c.String(200, "%s", c.FullPath())
+	})
+	if w := serve(r, "GET", "/hello/world"); w.Body.String() != "/hello/:name" {
+		t.Fatalf("expect /hello/:name, got %q", w.Body.String())
+	}
+	serve(r, "GET", "/missing")
+	if !reflect.DeepEqual(seen, []string{"/hello/:name", ""}) {
+		t.Fatalf("unexpected full paths seen by middleware: %q", seen)
+	}
+}
@@ -0,0 +1,104 @@
+﻿package gee
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout 限制后续处理链的执行时间，超时则返回 503 并终止请求。
+// 后续处理链在独立的 goroutine 中使用 Context 的副本执行，响应先写入缓冲区，
+// 按时完成才写回客户端；超时后处理函数的写入会被丢弃，避免与超时响应并发写。
+// 处理函数可通过 c.Request.Context() 感知超时。
+// 副本持有 Keys、Errors 的拷贝，按时完成才写回，超时后仍在运行的处理函数不会与外层中间件并发读写它们
+func Timeout(d time.Duration) HandlerFunc {
+	return func(c *Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+
+		tw := &timeoutWriter{w: c.Writer, h: make(http.Header)}
+		cc := *c // 副本，避免与当前 goroutine 并发修改 index 等字段
+		cc.Writer = tw
+		cc.Request = c.Request.WithContext(ctx)
+		cc.Errors = append([]error(nil), c.Errors...) // 独立的底层数组，两边追加互不影响
+		if c.Keys != nil {
+			cc.Keys = make(map[string]interface{}, len(c.Keys))
+			for k, v := range c.Keys {
+				cc.Keys[k] = v
+			}
+		}
+		done := make(chan struct{})
+		panicChan := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicChan <- p
+				}
+			}()
+			cc.Next()
+			close(done)
+		}()
+
+		select {
+		case p := <-panicChan:
+			panic(p) // 交给外层的 Recovery 处理
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			dst := c.Writer.Header()
+			for k, v := range tw.h {
+				dst[k] = v
+			}
+			if tw.code != 0 {
+				c.Writer.WriteHeader(tw.code)
+			}
+			_, _ = c.Writer.Write(tw.buf.Bytes())
+			c.index = cc.index
+			c.StatusCode = cc.StatusCode
+			c.Errors = cc.Errors
+			c.Keys = cc.Keys
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			tw.timedOut = true
+			c.index = len(c.handlers) // 跳过后续处理函数
+			c.Error(ctx.Err())
+			c.String(http.StatusServiceUnavailable, "Service Unavailable")
+		}
+	}
+}
+
+// timeoutWriter 缓存处理函数的响应，超时后丢弃所有写入
+type timeoutWriter struct {
+	w        http.ResponseWriter
+	h        http.Header
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.h }
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
@@ -0,0 +1,71 @@
+﻿package gee
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	r := New()
+	r.Use(Timeout(50 * time.Millisecond))
+	r.GET("/slow", func(c *Context) {
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "slow")
+	})
+	r.GET("/fast", func(c *Context) {
+		c.SetHeader("X-Fast", "1")
+		c.String(http.StatusCreated, "fast")
+	})
+
+	w := serve(r, "GET", "/slow")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expect 503 for slow handler, got %d %q", w.Code, w.Body.String())
+	}
+	w = serve(r, "GET", "/fast")
+	if w.Code != http.StatusCreated || w.Body.String() != "fast" || w.Header().Get("X-Fast") != "1" {
+		t.Fatalf("unexpected fast response %d %q", w.Code, w.Body.String())
+	}
+	time.Sleep(200 * time.Millisecond) // 等待超时的处理函数结束，确认其写入被丢弃
+}
+
+func TestTimeout_IsolatesKeysAndErrors(t *testing.T) {
+	r := New()
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	var outer *Context
+	r.Use(func(c *Context) {
+		c.Set("requestID", "abc")
+		c.Next()
+		outer = c
+	}, Timeout(20*time.Millisecond))
+	r.GET("/slow", func(c *Context) {
+		defer close(finished)
+		<-release // 超时后继续写入副本
+		for i := 0; i < 100; i++ {
+			c.Set("late", i)
+			c.Error(errors.New("late"))
+		}
+	})
+
+	w := serve(r, "GET", "/slow")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expect 503, got %d", w.Code)
+	}
+	close(release)
+	for i := 0; i < 100; i++ { // 与仍在运行的处理函数并发读取外层 Context
+		if id, _ := outer.GetString("requestID"); id != "abc" {
+			t.Fatalf("unexpected requestID %q", id)
+		}
+		_ = len(outer.Errors)
+	}
+	<-finished
+	if _, ok := outer.Get("late"); ok {
+		t.Fatal("keys set after timeout should not leak into the outer context")
+	}
+	if len(outer.Errors) != 1 || !errors.Is(outer.Errors[0], context.DeadlineExceeded) {
+		t.Fatalf("expect only the timeout error, got %v", outer.Errors)
+	}
+}
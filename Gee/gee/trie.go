@@ -1,50 +1,91 @@
 ﻿package gee
 
-import "strings"
+import (
+	"regexp"
+	"strings"
+)
 
 type node struct {
-	pattern  string  // 完整路由模式（仅叶子节点设置）
-	part     string  //路由分片，如 :id
-	children []*node //子节点列表
-	isWild   bool    //是否为通配符节点（含:或*）
+	pattern  string         // 完整路由模式（仅叶子节点设置）
+	part     string         //路由分片，如 :id
+	children []*node        //子节点列表
+	isWild   bool           //是否为通配符节点（含:或*）
+	regex    *regexp.Regexp //参数约束，如 :id(\d+) 中的 \d+，为nil时匹配任意分片
 }
 
-// 查找首个匹配成功的节点,用于插入
-func (n *node) matchChild(part string) *node {
+// 查找首个匹配成功的节点,用于插入，regex 为 part 的参数约束
+// 无约束的分片与已有的无约束通配节点合并；带约束的参数节点（如 :id(\d+)）只与分片完全相同的节点合并，
+// 因此 :id(\d+) 与 :name 互不合并，查找时依次尝试
+func (n *node) matchChild(part string, regex *regexp.Regexp) *node {
 	for _, child := range n.children { //遍历子节点
-		if child.part == part || child.isWild {
+		if child.part == part || child.isWild && child.regex == nil && regex == nil {
 			return child
 		}
 	}
 	return nil
 }
 
+// 判断节点能否匹配分片：静态节点需完全相同，参数节点需满足约束
+func (n *node) matchPart(part string) bool {
+	if n.part == part {
+		return true
+	}
+	return n.isWild && (n.regex == nil || n.regex.MatchString(part))
+}
+
+// 解析带约束的参数分片，返回参数名与约束表达式，如 :id(\d+) 返回 id 与 \d+，无约束时表达式为空
+// 约束中不能包含 /
+func parseParam(part string) (string, string) {
+	name := part[1:]
+	if part[0] != ':' || !strings.HasSuffix(part, ")") {
+		return name, ""
+	}
+	i := strings.Index(name, "(")
+	if i < 0 {
+		return name, ""
+	}
+	return name[:i], name[i+1 : len(name)-1]
+}
+
+// 编译参数分片的约束，整段匹配，如 :id(\d+) 编译为 ^(?:\d+)$；非参数分片或无约束时返回nil
+func compileParam(part string) (*regexp.Regexp, error) {
+	if part[0] != ':' {
+		return nil, nil
+	}
+	if _, expr := parseParam(part); expr != "" {
+		return regexp.Compile("^(?:" + expr + ")$")
+	}
+	return nil, nil
+}
+
 // 查找所有匹配成功的节点，用于查找
 func (n *node) matchChildren(part string) []*node {
 	nodes := make([]*node, 0)
 	for _, child := range n.children {
-		if child.part == part || child.isWild {
+		if child.matchPart(part) {
 			nodes = append(nodes, child)
 		}
 	}
 	return nodes
 }
 
-func (n *node) insert(pattern string, parts []string, height int) {
+// regexes 与 parts 一一对应，为各分片已编译的参数约束
+func (n *node) insert(pattern string, parts []string, regexes []*regexp.Regexp, height int) {
 	if len(parts) == height { //到达叶子节点
 		n.pattern = pattern
 		return
 	}
-	part := parts[height]       //获取当前分片
-	child := n.matchChild(part) //查找匹配的子节点
-	if child == nil {           //没有匹配的子节点，创建新节点
+	part := parts[height]                        //获取当前分片
+	child := n.matchChild(part, regexes[height]) //查找匹配的子节点
+	if child == nil {                            //没有匹配的子节点，创建新节点
 		child = &node{
 			part:   part,
 			isWild: part[0] == ':' || part[0] == '*',
+			regex:  regexes[height],
 		}
 		n.children = append(n.children, child)
 	}
-	child.insert(pattern, parts, height+1) //递归插入子节点
+	child.insert(pattern, parts, regexes, height+1) //递归插入子节点
 }
 
 func (n *node) search(parts []string, height int) *node {
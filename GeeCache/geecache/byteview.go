@@ -1,16 +1,23 @@
 ﻿package geecache
 
+import "bytes"
+
 // ByteView 表示一个不可变的字节序列视图
 // 用于封装缓存值，提供只读访问接口
 type ByteView struct {
 	b []byte // 底层字节切片（不可变）
 }
 
-// Len 返回字节视图的长度（实现 Value 接口）
+// Len 返回字节视图的长度（实现 Value 接口），即缓存占用的字节数
 func (v ByteView) Len() int {
 	return len(v.b)
 }
 
+// Equal 判断两个视图的内容是否相同，直接比较底层数据，无需复制
+func (v ByteView) Equal(other ByteView) bool {
+	return bytes.Equal(v.b, other.b)
+}
+
 // ByteSlice 返回底层字节切片的副本
 // 避免外部修改原始数据（防御性复制）
 func (v ByteView) ByteSlice() []byte {
@@ -0,0 +1,18 @@
+﻿package geecache
+
+import "testing"
+
+func TestByteView_Equal(t *testing.T) {
+	a := ByteView{b: []byte("630")}
+	b := ByteView{b: cloneBytes([]byte("630"))}
+	c := ByteView{b: []byte("589")}
+	if !a.Equal(b) || a.Len() != 3 {
+		t.Fatal("identical views should be equal")
+	}
+	if a.Equal(c) || a.Equal(ByteView{}) {
+		t.Fatal("different views shouldn't be equal")
+	}
+	if !(ByteView{}).Equal(ByteView{b: []byte{}}) {
+		t.Fatal("empty views should be equal")
+	}
+}
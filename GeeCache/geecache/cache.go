@@ -1,7 +1,9 @@
 ﻿package geecache
 
 import (
+	"hash/fnv"
 	"sync"
+	"time"
 
 	"github.com/nukecoke1828/7daysProgram/GeeCache/geecache/lru"
 )
@@ -30,11 +32,12 @@ func (c *cache) add(key string, value ByteView) {
 }
 
 // get 从缓存中获取值
-// 线程安全：使用互斥锁保护
 // 返回值：值（如果存在）和布尔值表示是否命中
+// 命中时只标记访问而不移动链表（lru.Peek），因此只需读锁，多个读可以并发；
+// 被标记的项目在淘汰时获得第二次机会，淘汰顺序近似LRU
 func (c *cache) get(key string) (value ByteView, ok bool) {
-	c.mu.Lock()         // 获取写锁（因为lru.Get会修改链表）
-	defer c.mu.Unlock() // 确保释放锁
+	c.mu.RLock()         // 获取读锁（lru.Peek不修改链表）
+	defer c.mu.RUnlock() // 确保释放锁
 
 	// 如果LRU缓存未初始化，直接返回未命中
 	if c.lru == nil {
@@ -42,10 +45,156 @@ func (c *cache) get(key string) (value ByteView, ok bool) {
 	}
 
 	// 从LRU缓存中获取值
-	if v, ok := c.lru.Get(key); ok {
+	if v, ok := c.lru.Peek(key); ok {
 		// 类型断言确保返回的是ByteView类型
 		return v.(ByteView), ok
 	}
 
 	return // 未命中
 }
+
+// remove 删除键，返回键是否存在
+func (c *cache) remove(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lru == nil {
+		return false
+	}
+	return c.lru.Remove(key)
+}
+
+// rangeAll 从最近使用到最久未使用遍历缓存，fn 返回 false 时停止
+// 不改变使用顺序，遍历期间持有锁
+func (c *cache) rangeAll(fn func(key string, value ByteView) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lru == nil {
+		return
+	}
+	c.lru.Range(func(key string, value lru.Value) bool {
+		return fn(key, value.(ByteView))
+	})
+}
+
+// shardedCache 按键的哈希把数据分散到多个独立加锁的 cache 分片，降低热点组的锁竞争
+// 每个分片各自执行LRU淘汰，容量为总容量平分
+type shardedCache struct {
+	shards []*cache
+}
+
+// newShardedCache 创建 n 个分片的缓存，n<=1 时只有一个分片，行为与单个 cache 相同
+func newShardedCache(cacheBytes int64, n int) *shardedCache {
+	if n < 1 {
+		n = 1
+	}
+	perShard := cacheBytes / int64(n)
+	if cacheBytes > 0 && perShard == 0 {
+		perShard = 1 // 0 表示不限制容量，不能因平分而丢失限制
+	}
+	s := &shardedCache{shards: make([]*cache, n)}
+	for i := range s.shards {
+		s.shards[i] = &cache{cacheBytes: perShard}
+	}
+	return s
+}
+
+// shard 返回键所在的分片
+func (s *shardedCache) shard(key string) *cache {
+	if len(s.shards) == 1 {
+		return s.shards[0]
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+func (s *shardedCache) add(key string, value ByteView) { s.shard(key).add(key, value) }
+
+func (s *shardedCache) get(key string) (ByteView, bool) { return s.shard(key).get(key) }
+
+func (s *shardedCache) remove(key string) bool { return s.shard(key).remove(key) }
+
+// rangeAll 依次遍历每个分片，分片内从最近使用到最久未使用，fn 返回 false 时停止
+func (s *shardedCache) rangeAll(fn func(key string, value ByteView) bool) {
+	stopped := false
+	for _, c := range s.shards {
+		c.rangeAll(func(key string, value ByteView) bool {
+			stopped = !fn(key, value)
+			return !stopped
+		})
+		if stopped {
+			return
+		}
+	}
+}
+
+// negativeCache 记录确认不存在的键（负缓存），避免重复未命中反复访问数据源
+// 过期条目在 contains 时惰性淘汰，并由 add 每隔一个有效期整体清理一次，
+// 大量不同的不存在键涌入时，每次 add 的均摊开销仍为 O(1)
+type negativeCache struct {
+	mu        sync.Mutex           // 互斥锁，保证并发安全
+	ttl       time.Duration        // 负缓存有效期
+	entries   map[string]time.Time // 键到过期时间的映射
+	nextSweep time.Time            // 下一次整体清理过期条目的时间
+}
+
+// add 记录一个不存在的键
+func (n *negativeCache) add(key string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.ttl <= 0 {
+		return
+	}
+	if n.entries == nil {
+		n.entries = make(map[string]time.Time)
+	}
+	now := time.Now()
+	// 每个有效期最多整体清理一次，防止负缓存无限增长
+	if !now.Before(n.nextSweep) {
+		for k, expire := range n.entries {
+			if !now.Before(expire) {
+				delete(n.entries, k)
+			}
+		}
+		n.nextSweep = now.Add(n.ttl)
+	}
+	n.entries[key] = now.Add(n.ttl)
+}
+
+// contains 判断键是否仍在负缓存中
+func (n *negativeCache) contains(key string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	expire, ok := n.entries[key]
+	if !ok {
+		return false
+	}
+	if !time.Now().Before(expire) {
+		delete(n.entries, key) // 过期即淘汰
+		return false
+	}
+	return true
+}
+
+// remove 删除键的负缓存记录
+func (n *negativeCache) remove(key string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.entries, key)
+}
+
+// setTTL 修改负缓存有效期，ttl<=0 时关闭负缓存并清空已有记录
+func (n *negativeCache) setTTL(ttl time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.ttl = ttl
+	n.nextSweep = time.Time{}
+	if ttl <= 0 {
+		n.entries = nil
+	}
+}
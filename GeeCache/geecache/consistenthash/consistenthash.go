@@ -81,3 +81,25 @@ func (m *Map) Get(key string) string {
 	// 通过虚拟节点哈希值找到对应的真实节点
 	return m.hashMap[targetHash]
 }
+
+// GetN 返回键在哈希环上顺时针依次遇到的至多 n 个不同真实节点，第一个即 Get 的结果
+func (m *Map) GetN(key string, n int) []string {
+	if len(m.keys) == 0 || n <= 0 {
+		return nil
+	}
+	hash := int(m.hash([]byte(key)))
+	idx := sort.Search(len(m.keys), func(i int) bool {
+		return m.keys[i] >= hash
+	})
+	var nodes []string
+	seen := make(map[string]bool)
+	// 从命中位置起绕环一周，跳过同一真实节点的其他虚拟节点
+	for i := 0; i < len(m.keys) && len(nodes) < n; i++ {
+		node := m.hashMap[m.keys[(idx+i)%len(m.keys)]]
+		if !seen[node] {
+			seen[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
@@ -47,6 +47,25 @@ func TestHashing(t *testing.T) {
 	}
 }
 
+// TestGetN 测试按顺时针顺序取多个不同的真实节点
+func TestGetN(t *testing.T) {
+	hash := New(3, func(key []byte) uint32 {
+		i, _ := strconv.Atoi(string(key))
+		return uint32(i)
+	})
+	hash.Add("6", "4", "2") // 环: 2 4 6 12 14 16 22 24 26
+
+	if got := hash.GetN("11", 2); len(got) != 2 || got[0] != "2" || got[1] != "4" {
+		t.Errorf("键 11 应依次命中 2 4, 实际 %v", got)
+	}
+	if got := hash.GetN("27", 5); len(got) != 3 || got[0] != "2" || got[1] != "4" || got[2] != "6" {
+		t.Errorf("节点不足时应返回全部节点, 实际 %v", got)
+	}
+	if got := hash.GetN("1", 0); got != nil {
+		t.Errorf("n 为 0 时应返回空, 实际 %v", got)
+	}
+}
+
 /*
 测试说明：
 
@@ -1,9 +1,13 @@
 ﻿package geecache
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	pb "github.com/nukecoke1828/7daysProgram/GeeCache/geecache/geecachepb"
 	"github.com/nukecoke1828/7daysProgram/GeeCache/geecache/singleflight"
@@ -15,6 +19,10 @@ var (
 	groups = make(map[string]*Group) // 存储所有缓存组的映射表（组名->缓存组）
 )
 
+// ErrNotFound 表示键在数据源中确实不存在（区别于临时性的加载失败）
+// Getter 返回该错误（或包装了该错误）时，Group 会在短时间内缓存这次未命中
+var ErrNotFound = errors.New("geecache: key not found")
+
 // GetterFunc 函数类型适配器，允许普通函数实现Getter接口
 type GetterFunc func(key string) ([]byte, error)
 
@@ -23,13 +31,42 @@ type Getter interface {
 	Get(key string) ([]byte, error) // 从底层数据源获取数据
 }
 
+// ContextGetter 支持上下文的数据获取接口
+// Getter 同时实现该接口时，Group 会把调用方的上下文传给数据源，便于取消慢加载
+type ContextGetter interface {
+	GetContext(ctx context.Context, key string) ([]byte, error)
+}
+
+// ContextGetterFunc 函数类型适配器，允许普通函数实现Getter和ContextGetter接口
+type ContextGetterFunc func(ctx context.Context, key string) ([]byte, error)
+
+// Get 实现Getter接口，使用后台上下文
+func (f ContextGetterFunc) Get(key string) ([]byte, error) {
+	return f(context.Background(), key)
+}
+
+// GetContext 实现ContextGetter接口
+func (f ContextGetterFunc) GetContext(ctx context.Context, key string) ([]byte, error) {
+	return f(ctx, key)
+}
+
 // Group 表示一个命名的缓存组（缓存命名空间）
 type Group struct {
 	name      string              // 缓存组名称（唯一标识）
 	getter    Getter              // 数据获取器（缓存未命中时调用）
-	mainCache cache               // 主缓存（并发安全的LRU缓存封装）
+	mainCache *shardedCache       // 主缓存（并发安全的分片LRU缓存封装）
 	peers     PeerPicker          // 节点选择器（用于分布式缓存）
 	loader    *singleflight.Group // 单飞组（防止缓存击穿）
+	stats     Stats               // 统计信息（原子更新）
+	negative  negativeCache       // 负缓存（记录不存在的键），默认关闭，通过 EnableNegativeCache 开启
+	loadTTL   time.Duration       // 单次加载的最长时间，0 表示不限制
+}
+
+// Stats 缓存组的统计信息
+type Stats struct {
+	Gets           int64 // 请求次数（不含空键）
+	Hits           int64 // 本地缓存命中次数
+	CoalescedLoads int64 // 与其他并发请求合并、共享同一次加载结果的请求数
 }
 
 // Get 实现Getter接口，允许GetterFunc类型作为Getter
@@ -37,14 +74,34 @@ func (f GetterFunc) Get(key string) ([]byte, error) {
 	return f(key) // 直接调用底层函数
 }
 
+// GroupOptions 缓存组的可选配置
+type GroupOptions struct {
+	// Shards 主缓存的分片数，各分片独立加锁、平分容量，<=1 时不分片
+	Shards int
+	// LoadTimeout 单次加载（远程节点或数据源）的最长时间，0 表示不限制。
+	// 加载由合并的多个请求共享，不随任一调用方的取消而中止，只受此超时约束
+	LoadTimeout time.Duration
+}
+
 // NewGroup 创建并注册一个新的缓存组
 // name: 组名（必须全局唯一）
 // cacheBytes: 缓存容量（字节）
 // getter: 数据获取器（不能为nil）
 func NewGroup(name string, cacheBytes int64, getter Getter) *Group {
+	return NewGroupOpts(name, cacheBytes, getter, nil)
+}
+
+// NewGroupOpts 创建可配置分片数等选项的缓存组，opts 为 nil 时与 NewGroup 相同
+func NewGroupOpts(name string, cacheBytes int64, getter Getter, opts *GroupOptions) *Group {
 	if getter == nil {
 		panic("nil Getter") // 防止空数据获取器
 	}
+	shards := 1
+	var loadTTL time.Duration
+	if opts != nil {
+		shards = opts.Shards
+		loadTTL = opts.LoadTimeout
+	}
 
 	mu.Lock()         // 获取全局写锁
 	defer mu.Unlock() // 确保释放锁
@@ -53,8 +110,9 @@ func NewGroup(name string, cacheBytes int64, getter Getter) *Group {
 	g := &Group{
 		name:      name,
 		getter:    getter,
-		mainCache: cache{cacheBytes: cacheBytes}, // 初始化底层缓存
-		loader:    &singleflight.Group{},         // 初始化单飞组
+		mainCache: newShardedCache(cacheBytes, shards), // 初始化底层缓存
+		loader:    &singleflight.Group{},               // 初始化单飞组
+		loadTTL:   loadTTL,
 	}
 	groups[name] = g // 注册到全局映射表
 	return g
@@ -71,25 +129,77 @@ func GetGroup(name string) *Group {
 // key: 要查询的缓存键
 // 返回值: 缓存值视图或错误
 func (g *Group) Get(key string) (ByteView, error) {
+	return g.GetContext(context.Background(), key)
+}
+
+// GetContext 带上下文地从缓存组获取数据
+// ctx 被取消或超时后立即返回 ctx.Err()，不再等待正在进行的加载。
+// 加载由并发的相同请求共享，使用脱离调用方取消的上下文（保留其中的值），
+// 因此某个调用方取消不会让其他仍在等待的调用方失败
+func (g *Group) GetContext(ctx context.Context, key string) (ByteView, error) {
 	if key == "" {
 		return ByteView{}, fmt.Errorf("key is required") // 空键检查
 	}
 
+	atomic.AddInt64(&g.stats.Gets, 1)
 	// 1. 尝试从本地缓存获取
 	if v, ok := g.mainCache.get(key); ok {
+		atomic.AddInt64(&g.stats.Hits, 1)
 		log.Println("[GeeCache] hit") // 缓存命中日志
 		return v, nil
 	}
 
-	// 2. 缓存未命中，加载数据
-	return g.load(key)
+	// 2. 近期确认不存在的键直接返回，不再访问数据源
+	if g.negative.contains(key) {
+		return ByteView{}, ErrNotFound
+	}
+
+	// 3. 缓存未命中，加载数据
+	if err := ctx.Err(); err != nil {
+		return ByteView{}, err
+	}
+	type result struct {
+		value ByteView
+		err   error
+	}
+	ch := make(chan result, 1) // 带缓冲，调用方提前返回时加载协程不会阻塞
+	go func() {
+		loadCtx, cancel := g.loadContext(ctx)
+		defer cancel()
+		value, err := g.load(loadCtx, key)
+		ch <- result{value, err}
+	}()
+	select {
+	case res := <-ch:
+		return res.value, res.err
+	case <-ctx.Done():
+		return ByteView{}, ctx.Err()
+	}
+}
+
+// loadContext 返回共享加载使用的上下文：不随 ctx 取消，配置了 LoadTimeout 时带超时
+func (g *Group) loadContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached := context.WithoutCancel(ctx)
+	if g.loadTTL > 0 {
+		return context.WithTimeout(detached, g.loadTTL)
+	}
+	return context.WithCancel(detached)
 }
 
 // getLocally 从本地数据源获取数据并填充缓存
-func (g *Group) getLocally(key string) (ByteView, error) {
-	// 1. 调用用户提供的数据获取器
-	bytes, err := g.getter.Get(key)
+func (g *Group) getLocally(ctx context.Context, key string) (ByteView, error) {
+	// 1. 调用用户提供的数据获取器（支持上下文时传入ctx）
+	var bytes []byte
+	var err error
+	if cg, ok := g.getter.(ContextGetter); ok {
+		bytes, err = cg.GetContext(ctx, key)
+	} else {
+		bytes, err = g.getter.Get(key)
+	}
 	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			g.negative.add(key) // 记录不存在的键
+		}
 		return ByteView{}, err // 转发数据获取错误
 	}
 
@@ -101,11 +211,37 @@ func (g *Group) getLocally(key string) (ByteView, error) {
 	return value, nil
 }
 
+// EnableNegativeCache 设置不存在键的缓存时长
+// ttl<=0 时关闭负缓存，每次未命中都会访问数据源
+func (g *Group) EnableNegativeCache(ttl time.Duration) {
+	g.negative.setTTL(ttl)
+}
+
+// Set 直接写入本地缓存，并清除该键的负缓存记录
+func (g *Group) Set(key string, value []byte) {
+	g.negative.remove(key)
+	g.populateCache(key, ByteView{b: cloneBytes(value)})
+}
+
+// Remove 从本地缓存中删除键，下次访问时重新加载
+func (g *Group) Remove(key string) {
+	g.mainCache.remove(key)
+}
+
 // populateCache 将数据添加到本地缓存
 func (g *Group) populateCache(key string, value ByteView) {
 	g.mainCache.add(key, value) // 添加到主缓存
 }
 
+// Stats 返回缓存组统计信息的快照
+func (g *Group) Stats() Stats {
+	return Stats{
+		Gets:           atomic.LoadInt64(&g.stats.Gets),
+		Hits:           atomic.LoadInt64(&g.stats.Hits),
+		CoalescedLoads: atomic.LoadInt64(&g.stats.CoalescedLoads),
+	}
+}
+
 // RegisterPeers 注册节点选择器（用于分布式缓存）
 // peers: 实现了PeerPicker接口的对象
 func (g *Group) RegisterPeers(peers PeerPicker) {
@@ -118,13 +254,13 @@ func (g *Group) RegisterPeers(peers PeerPicker) {
 // load 数据加载方法（带单飞机制）
 // 1. 尝试从远程节点获取
 // 2. 失败则从本地数据源获取
-func (g *Group) load(key string) (value ByteView, err error) {
+func (g *Group) load(ctx context.Context, key string) (value ByteView, err error) {
 	// 使用单飞机制确保相同键的请求只执行一次
-	viewi, err := g.loader.Do(key, func() (interface{}, error) {
+	viewi, err, shared := g.loader.Do(key, func() (interface{}, error) {
 		// 1. 如果配置了分布式节点
 		if g.peers != nil {
-			// 选择远程节点
-			if peer, ok := g.peers.PickPeer(key); ok {
+			// 选择远程节点，支持副本读取时依次尝试主节点和副本
+			for _, peer := range g.pickPeers(key) {
 				// 尝试从远程节点获取
 				if value, err = g.getFromPeer(peer, key); err == nil {
 					return value, nil
@@ -135,8 +271,11 @@ func (g *Group) load(key string) (value ByteView, err error) {
 		}
 
 		// 2. 从本地数据源获取（最终回退）
-		return g.getLocally(key)
+		return g.getLocally(ctx, key)
 	})
+	if shared {
+		atomic.AddInt64(&g.stats.CoalescedLoads, 1) // 记录被合并的加载
+	}
 
 	if err == nil {
 		return viewi.(ByteView), nil // 类型断言获取结果
@@ -144,6 +283,17 @@ func (g *Group) load(key string) (value ByteView, err error) {
 	return
 }
 
+// pickPeers 返回键的候选远程节点，节点选择器实现了 MultiPeerPicker 时包含副本
+func (g *Group) pickPeers(key string) []PeerGetter {
+	if mp, ok := g.peers.(MultiPeerPicker); ok {
+		return mp.PickPeers(key)
+	}
+	if peer, ok := g.peers.PickPeer(key); ok {
+		return []PeerGetter{peer}
+	}
+	return nil
+}
+
 // getFromPeer 从远程节点获取数据
 // peer: 实现了PeerGetter接口的远程节点
 // key: 要查询的键
@@ -1,10 +1,17 @@
 ﻿package geecache
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
 	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // 模拟数据库
@@ -75,3 +82,369 @@ func TestGet(t *testing.T) {
 		t.Fatalf("Expected error for unknown key, got value: %s", view)
 	}
 }
+
+// TestCoalescedLoads 测试并发请求同一冷键时只加载一次并记录合并次数
+func TestCoalescedLoads(t *testing.T) {
+	var loads int32
+	gee := NewGroup("coalesce", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			atomic.AddInt32(&loads, 1)
+			time.Sleep(100 * time.Millisecond) // 模拟慢查询，让并发请求合并
+			return []byte(db[key]), nil
+		}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if view, err := gee.Get("Tom"); err != nil || view.String() != "630" {
+				t.Errorf("Failed to get Tom: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Fatalf("getter should run once, ran %d times", n)
+	}
+	if stats := gee.Stats(); stats.CoalescedLoads <= 0 {
+		t.Fatalf("expected coalesced loads, got %d", stats.CoalescedLoads)
+	}
+}
+
+// TestNegativeCache 测试负缓存默认关闭，开启后数据源返回ErrNotFound时，立即再次获取由负缓存返回
+func TestNegativeCache(t *testing.T) {
+	var loads int32
+	gee := NewGroup("negative", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			atomic.AddInt32(&loads, 1)
+			return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+		}))
+
+	// 默认关闭：每次未命中都访问数据源
+	for i := 0; i < 2; i++ {
+		if _, err := gee.Get("missing"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expect ErrNotFound, got %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&loads); n != 2 {
+		t.Fatalf("negative cache should be off by default, getter ran %d times", n)
+	}
+
+	gee.EnableNegativeCache(time.Second)
+	for i := 0; i < 2; i++ {
+		if _, err := gee.Get("missing"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expect ErrNotFound, got %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&loads); n != 3 {
+		t.Fatalf("getter should run once after enabling, ran %d times in total", n)
+	}
+}
+
+// TestEnableNegativeCache 测试负缓存的有效期、过期淘汰以及Set后清除
+func TestEnableNegativeCache(t *testing.T) {
+	var loads int32
+	gee := NewGroup("negative-ttl", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			atomic.AddInt32(&loads, 1)
+			return nil, ErrNotFound
+		}))
+	gee.EnableNegativeCache(50 * time.Millisecond)
+
+	// 有效期内只访问一次数据源
+	for i := 0; i < 5; i++ {
+		if _, err := gee.Get("missing"); err != ErrNotFound {
+			t.Fatalf("expect ErrNotFound, got %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Fatalf("getter should run once within ttl, ran %d times", n)
+	}
+
+	// 过期后重新访问数据源
+	time.Sleep(60 * time.Millisecond)
+	gee.Get("missing")
+	if n := atomic.LoadInt32(&loads); n != 2 {
+		t.Fatalf("getter should run again after ttl, ran %d times", n)
+	}
+
+	// Set 清除负缓存
+	gee.Set("missing", []byte("found"))
+	if view, err := gee.Get("missing"); err != nil || view.String() != "found" {
+		t.Fatalf("expect found after Set, got %v %v", view, err)
+	}
+
+	// 关闭负缓存后每次都访问数据源
+	gee.EnableNegativeCache(0)
+	gee.Get("other")
+	gee.Get("other")
+	if n := atomic.LoadInt32(&loads); n != 4 {
+		t.Fatalf("getter should run on every miss when disabled, ran %d times", n)
+	}
+}
+
+// pickerFunc 把函数适配为 PeerPicker
+type pickerFunc func(key string) (PeerGetter, bool)
+
+func (f pickerFunc) PickPeer(key string) (PeerGetter, bool) { return f(key) }
+
+// TestGetFromPeer 使用 MockPeerGetter 测试从远程节点获取数据，无需网络
+func TestGetFromPeer(t *testing.T) {
+	peer := NewMockPeerGetter(map[string]string{"Tom": "630"})
+	var loads int32
+	gee := NewGroup("mock-peer", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			atomic.AddInt32(&loads, 1)
+			return []byte("local"), nil
+		}))
+	gee.RegisterPeers(pickerFunc(func(key string) (PeerGetter, bool) {
+		return peer, true
+	}))
+
+	if view, err := gee.getFromPeer(peer, "Tom"); err != nil || view.String() != "630" {
+		t.Fatalf("Failed to get from peer: %s %v", view, err)
+	}
+	if _, err := gee.getFromPeer(peer, "Jack"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect ErrNotFound, got %v", err)
+	}
+
+	// 远程命中不访问本地数据源，远程失败时回退到本地
+	if view, err := gee.Get("Tom"); err != nil || view.String() != "630" || atomic.LoadInt32(&loads) != 0 {
+		t.Fatalf("expect value from peer, got %s %v", view, err)
+	}
+	peer.Err = fmt.Errorf("peer down")
+	if view, err := gee.Get("Sam"); err != nil || view.String() != "local" || atomic.LoadInt32(&loads) != 1 {
+		t.Fatalf("expect fallback to local getter, got %s %v", view, err)
+	}
+	if n := len(peer.Requests); n != 4 || peer.Requests[0].Group != "mock-peer" {
+		t.Fatalf("expect 4 recorded requests, got %d", n)
+	}
+}
+
+// TestShardedCache 测试分片缓存在多个分片间读写、删除与遍历的正确性
+func TestShardedCache(t *testing.T) {
+	c := newShardedCache(0, 8)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				key := strconv.Itoa(w*100 + i)
+				c.add(key, ByteView{b: []byte(key)})
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	used := 0
+	for _, shard := range c.shards {
+		if shard.lru != nil && shard.lru.Len() > 0 {
+			used++
+		}
+	}
+	if used < 2 {
+		t.Fatalf("expect keys spread across shards, only %d used", used)
+	}
+	for i := 0; i < 800; i++ {
+		key := strconv.Itoa(i)
+		if v, ok := c.get(key); !ok || v.String() != key {
+			t.Fatalf("expect %s in sharded cache, got %v %v", key, v, ok)
+		}
+	}
+	if !c.remove("42") {
+		t.Fatal("failed to remove key from its shard")
+	}
+	if _, ok := c.get("42"); ok {
+		t.Fatal("removed key should miss")
+	}
+	n := 0
+	c.rangeAll(func(key string, value ByteView) bool {
+		n++
+		return true
+	})
+	if n != 799 {
+		t.Fatalf("expect 799 entries across shards, got %d", n)
+	}
+
+	g := NewGroupOpts("sharded", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), &GroupOptions{Shards: 4})
+	if len(g.mainCache.shards) != 4 {
+		t.Fatalf("expect 4 shards, got %d", len(g.mainCache.shards))
+	}
+	if v, err := g.Get("Tom"); err != nil || v.String() != "Tom" {
+		t.Fatalf("failed to get from sharded group: %v %v", v, err)
+	}
+	g.Remove("Tom")
+	if _, ok := g.mainCache.get("Tom"); ok {
+		t.Fatal("Group.Remove should drop the key from its shard")
+	}
+}
+
+// BenchmarkCacheGetParallel 对比单分片与多分片缓存的并发读吞吐
+func BenchmarkCacheGetParallel(b *testing.B) {
+	for _, shards := range []int{1, 16} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			c := newShardedCache(0, shards)
+			keys := make([]string, 1024)
+			for i := range keys {
+				keys[i] = strconv.Itoa(i)
+				c.add(keys[i], ByteView{b: []byte(keys[i])})
+			}
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					c.get(keys[i%len(keys)])
+					i++
+				}
+			})
+		})
+	}
+}
+
+// TestCacheGetInfluencesEviction 读锁路径上的命中仍会影响淘汰顺序
+func TestCacheGetInfluencesEviction(t *testing.T) {
+	c := &cache{cacheBytes: 6} // 每项 "kN"+"v" 占 3 字节，只能容纳两项
+	c.add("k1", ByteView{b: []byte("a")})
+	c.add("k2", ByteView{b: []byte("b")})
+	if _, ok := c.get("k1"); !ok {
+		t.Fatal("expect k1 hit")
+	}
+	c.add("k3", ByteView{b: []byte("c")}) // k1 最近被读过，淘汰 k2
+	if _, ok := c.get("k2"); ok {
+		t.Fatal("expect k2 evicted")
+	}
+	if _, ok := c.get("k1"); !ok {
+		t.Fatal("expect recently read k1 kept")
+	}
+}
+
+// BenchmarkCacheGetLock 对比写锁+移动链表与读锁+标记访问两种命中路径的并发读吞吐
+func BenchmarkCacheGetLock(b *testing.B) {
+	c := &cache{}
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+		c.add(keys[i], ByteView{b: []byte(keys[i])})
+	}
+	b.Run("mutex", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				c.mu.Lock()
+				c.lru.Get(keys[i%len(keys)])
+				c.mu.Unlock()
+				i++
+			}
+		})
+	})
+	b.Run("rlock", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				c.get(keys[i%len(keys)])
+				i++
+			}
+		})
+	})
+}
+
+// TestStaticGetterLoopback 本地键由 StaticGetter 加载并缓存，远程键经 LoopbackPicker 读取
+func TestStaticGetterLoopback(t *testing.T) {
+	local := map[string][]byte{"Tom": []byte("630")}
+	remote := map[string][]byte{"Jack": []byte("589")}
+	g := NewGroup("loopback", 2<<10, StaticGetter(local))
+	g.RegisterPeers(NewLoopbackPicker(remote))
+	local["Tom"][0] = 'x' // 调用方修改原始数据不影响 Getter
+
+	for i := 0; i < 2; i++ {
+		if v, err := g.Get("Tom"); err != nil || v.String() != "630" {
+			t.Fatalf("get local key failed: %v %v", v, err)
+		}
+		if v, err := g.Get("Jack"); err != nil || v.String() != "589" {
+			t.Fatalf("get remote key failed: %v %v", v, err)
+		}
+	}
+	if _, err := g.Get("Sam"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect ErrNotFound for unknown key, got %v", err)
+	}
+	// 本地键第二次命中缓存，远程键不写入本地缓存
+	if stats := g.Stats(); stats.Gets != 5 || stats.Hits != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+// BenchmarkGroupGetHitRatio 在不同本地命中率下测量 Group.Get 的并发吞吐
+// 命中的键预先加载到本地缓存，未命中的键每次都经 LoopbackPicker 读取，不涉及网络
+func BenchmarkGroupGetHitRatio(b *testing.B) {
+	log.SetOutput(ioutil.Discard) // 屏蔽命中日志，避免干扰测量
+	defer log.SetOutput(os.Stderr)
+
+	const n = 1024
+	local := make(map[string][]byte, n)
+	remote := make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		local["hot-"+strconv.Itoa(i)] = []byte(strconv.Itoa(i))
+		remote["cold-"+strconv.Itoa(i)] = []byte(strconv.Itoa(i))
+	}
+	picker := NewLoopbackPicker(remote)
+
+	for _, ratio := range []int{50, 90, 99} {
+		b.Run(fmt.Sprintf("hit=%d%%", ratio), func(b *testing.B) {
+			g := NewGroup(fmt.Sprintf("bench-hit-%d", ratio), 0, StaticGetter(local))
+			g.RegisterPeers(picker)
+			// 每 100 个请求中 ratio 个访问已缓存的本地键
+			keys := make([]string, n)
+			for i := range keys {
+				if i%100 < ratio {
+					keys[i] = "hot-" + strconv.Itoa(i%n)
+					g.Get(keys[i])
+				} else {
+					keys[i] = "cold-" + strconv.Itoa(i%n)
+				}
+			}
+			start := g.Stats()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					g.Get(keys[i%len(keys)])
+					i++
+				}
+			})
+			b.StopTimer()
+			end := g.Stats()
+			b.ReportMetric(float64(end.Hits-start.Hits)/float64(end.Gets-start.Gets), "hit-ratio")
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "gets/s")
+		})
+	}
+}
+
+// TestNegativeCacheSweep 过期条目惰性淘汰，add 每个有效期最多整体清理一次
+func TestNegativeCacheSweep(t *testing.T) {
+	n := &negativeCache{ttl: 30 * time.Millisecond}
+	for i := 0; i < 100; i++ {
+		n.add(strconv.Itoa(i))
+	}
+	if len(n.entries) != 100 {
+		t.Fatalf("expect 100 entries, got %d", len(n.entries))
+	}
+	time.Sleep(40 * time.Millisecond)
+	if n.contains("0") {
+		t.Fatal("expired key should not be contained")
+	}
+	if _, ok := n.entries["0"]; ok {
+		t.Fatal("expired key should be removed lazily by contains")
+	}
+	n.add("new") // 距上次清理已超过有效期，清理所有过期条目
+	if len(n.entries) != 1 || !n.contains("new") {
+		t.Fatalf("expect only the new key after sweep, got %d entries", len(n.entries))
+	}
+	n.add("newer") // 有效期内不再清理
+	if len(n.entries) != 2 {
+		t.Fatalf("expect 2 entries, got %d", len(n.entries))
+	}
+}
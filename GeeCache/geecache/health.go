@@ -0,0 +1,51 @@
+﻿package geecache
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	healthPath = "/healthz" // 存活探针路径
+	readyPath  = "/readyz"  // 就绪探针路径
+)
+
+// Health 是健康检查接口返回的节点概况
+type Health struct {
+	Status   string  `json:"status"`    // "ok" 或 "not ready"
+	Groups   int     `json:"groups"`    // 已注册的缓存组数量
+	Gets     int64   `json:"gets"`      // 所有缓存组的请求次数
+	Hits     int64   `json:"hits"`      // 所有缓存组的本地命中次数
+	HitRatio float64 `json:"hit_ratio"` // 命中率，没有请求时为 0
+}
+
+// nodeHealth 汇总所有缓存组的统计信息
+func nodeHealth() Health {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	h := Health{Status: "ok", Groups: len(groups)}
+	for _, g := range groups {
+		stats := g.Stats()
+		h.Gets += stats.Gets
+		h.Hits += stats.Hits
+	}
+	if h.Gets > 0 {
+		h.HitRatio = float64(h.Hits) / float64(h.Gets)
+	}
+	return h
+}
+
+// serveHealth 以 JSON 返回节点概况；ready 为 true 时作为就绪探针，
+// 还没有注册任何缓存组的节点返回 503
+func serveHealth(w http.ResponseWriter, ready bool) {
+	h := nodeHealth()
+	code := http.StatusOK
+	if ready && h.Groups == 0 {
+		h.Status = "not ready"
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(h)
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/nukecoke1828/7daysProgram/GeeCache/geecache/consistenthash"
@@ -17,12 +19,17 @@ import (
 const (
 	defaultBasePath = "/_geecache/" // 默认的HTTP请求路径前缀
 	defaultReplicas = 50            // 默认的虚拟节点副本数量（用于一致性哈希）
+
+	defaultMaxIdleConnsPerHost = 16               // 默认每个节点保留的空闲连接数
+	defaultIdleConnTimeout     = 90 * time.Second // 默认空闲连接的保留时间
+	defaultDialTimeout         = 5 * time.Second  // 默认建立连接的超时时间
 )
 
 // 接口实现验证（编译时检查）
 var (
-	_ PeerGetter = (*httpGetter)(nil) // 确保httpGetter实现了PeerGetter接口
-	_ PeerPicker = (*HTTPPool)(nil)   // 确保HTTPPool实现了PeerPicker接口
+	_ PeerGetter      = (*httpGetter)(nil) // 确保httpGetter实现了PeerGetter接口
+	_ PeerPicker      = (*HTTPPool)(nil)   // 确保HTTPPool实现了PeerPicker接口
+	_ MultiPeerPicker = (*HTTPPool)(nil)   // 确保HTTPPool实现了MultiPeerPicker接口
 )
 
 // HTTPPool 实现了一个HTTP服务器池，用于提供分布式缓存服务
@@ -33,20 +40,71 @@ type HTTPPool struct {
 	mu          sync.Mutex             // 保护peers和httpGetters的互斥锁
 	peers       *consistenthash.Map    // 一致性哈希映射，用于节点选择
 	httpGetters map[string]*httpGetter // 节点地址到对应httpGetter的映射
+	opts        HTTPPoolOptions        // 一致性哈希配置
+	client      *http.Client           // 访问其他节点使用的HTTP客户端，由所有httpGetter共享
+}
+
+// HTTPPoolOptions 一致性哈希环的可选配置
+type HTTPPoolOptions struct {
+	Replicas int                 // 每个节点的虚拟节点数量，<=0 时使用默认值50
+	HashFn   consistenthash.Hash // 哈希函数，nil 时使用默认的CRC32
+	// ReadReplicas 主节点读取失败时，继续尝试哈希环上的后续节点数量，0 表示只访问主节点
+	ReadReplicas int
+	// Client 访问其他节点使用的HTTP客户端，设置后忽略下面的连接池配置
+	Client *http.Client
+	// MaxIdleConnsPerHost 每个节点保留的空闲连接数，<=0 时使用默认值16
+	MaxIdleConnsPerHost int
+	// Timeout 单次节点请求的超时时间（包含读取响应体），0 表示不限制
+	Timeout time.Duration
 }
 
 // httpGetter 实现PeerGetter接口，用于向其他节点发送HTTP请求获取缓存
 type httpGetter struct {
-	baseURL string // 基础URL格式：节点地址 + basePath（如"http://localhost:8000/_geecache/"）
+	baseURL string       // 基础URL格式：节点地址 + basePath（如"http://localhost:8000/_geecache/"）
+	client  *http.Client // 所属HTTPPool的HTTP客户端
 }
 
 // NewHTTPPool 创建并返回一个新的HTTPPool实例
 // self: 当前节点的网络地址（如"localhost:8000"）
 func NewHTTPPool(self string) *HTTPPool {
-	return &HTTPPool{
+	return NewHTTPPoolOpts(self, nil)
+}
+
+// NewHTTPPoolOpts 创建可配置哈希函数和虚拟节点数量的HTTPPool
+// opts 为 nil 时与 NewHTTPPool 相同
+func NewHTTPPoolOpts(self string, opts *HTTPPoolOptions) *HTTPPool {
+	p := &HTTPPool{
 		self:     self,
 		basePath: defaultBasePath, // 使用默认路径前缀
 	}
+	if opts != nil {
+		p.opts = *opts
+	}
+	if p.opts.Replicas <= 0 {
+		p.opts.Replicas = defaultReplicas
+	}
+	if p.opts.MaxIdleConnsPerHost <= 0 {
+		p.opts.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	p.client = p.opts.Client
+	if p.client == nil {
+		p.client = newPeerClient(p.opts)
+	}
+	return p
+}
+
+// newPeerClient 创建节点间通信专用的HTTP客户端
+// 使用独立的Transport，避免与 http.DefaultTransport 共享连接池
+func newPeerClient(opts HTTPPoolOptions) *http.Client {
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: defaultDialTimeout, KeepAlive: 30 * time.Second}).DialContext,
+		MaxIdleConns:          opts.MaxIdleConnsPerHost * 4,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		IdleConnTimeout:       defaultIdleConnTimeout,
+		ResponseHeaderTimeout: opts.Timeout,
+	}
+	return &http.Client{Transport: transport, Timeout: opts.Timeout}
 }
 
 // Log 提供带节点标识的日志记录功能
@@ -58,6 +116,16 @@ func (p *HTTPPool) Log(format string, v ...interface{}) {
 // 请求路径格式：/[basePath]/[groupName]/[key]
 // 处理流程：验证路径 → 提取组名和键 → 获取缓存组 → 查询键值 → 返回结果
 func (p *HTTPPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// 0. 健康检查与就绪探针，不经过缓存路径校验
+	switch r.URL.Path {
+	case healthPath:
+		serveHealth(w, false)
+		return
+	case readyPath:
+		serveHealth(w, true)
+		return
+	}
+
 	// 1. 验证请求路径前缀
 	if !strings.HasPrefix(r.URL.Path, p.basePath) {
 		http.Error(w, "HTTPPool serving unexpected path: "+r.URL.Path, http.StatusBadRequest)
@@ -85,8 +153,8 @@ func (p *HTTPPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 4. 从缓存组获取值
-	view, err := group.Get(key)
+	// 4. 从缓存组获取值（请求方断开时取消加载）
+	view, err := group.GetContext(r.Context(), key)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -117,7 +185,7 @@ func (h *httpGetter) Get(in *pb.Request, out *pb.Response) error {
 	)
 
 	// 发送HTTP GET请求
-	res, err := http.Get(u)
+	res, err := h.client.Get(u)
 	if err != nil {
 		return err
 	}
@@ -147,8 +215,8 @@ func (p *HTTPPool) Set(peers ...string) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	// 创建一致性哈希映射（使用默认副本数）
-	p.peers = consistenthash.New(defaultReplicas, nil)
+	// 创建一致性哈希映射（使用配置的副本数和哈希函数）
+	p.peers = consistenthash.New(p.opts.Replicas, p.opts.HashFn)
 	// 添加所有节点到哈希环
 	p.peers.Add(peers...)
 
@@ -156,7 +224,7 @@ func (p *HTTPPool) Set(peers ...string) {
 	p.httpGetters = make(map[string]*httpGetter, len(peers))
 	for _, peer := range peers {
 		// 为每个节点创建访问器（基础URL = 节点地址 + 基础路径）
-		p.httpGetters[peer] = &httpGetter{baseURL: peer + p.basePath}
+		p.httpGetters[peer] = &httpGetter{baseURL: peer + p.basePath, client: p.client}
 	}
 }
 
@@ -176,3 +244,23 @@ func (p *HTTPPool) PickPeer(key string) (PeerGetter, bool) {
 	// 如果选择的是当前节点或未找到节点，返回nil
 	return nil, false
 }
+
+// PickPeers 实现MultiPeerPicker接口，返回键的主节点及其后 ReadReplicas 个后继节点
+// 主节点是当前节点时返回空，由本节点加载；后继中的当前节点会被跳过
+func (p *HTTPPool) PickPeers(key string) []PeerGetter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	nodes := p.peers.GetN(key, 1+p.opts.ReadReplicas)
+	if len(nodes) == 0 || nodes[0] == p.self {
+		return nil
+	}
+	p.Log("Pick peers %v", nodes)
+	getters := make([]PeerGetter, 0, len(nodes))
+	for _, node := range nodes {
+		if node != p.self {
+			getters = append(getters, p.httpGetters[node])
+		}
+	}
+	return getters
+}
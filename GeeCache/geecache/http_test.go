@@ -0,0 +1,296 @@
+﻿package geecache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/nukecoke1828/7daysProgram/GeeCache/geecache/geecachepb"
+)
+
+// TestNewHTTPPoolOpts 使用确定性哈希验证键的节点分布，
+// 注入的哈希函数同时用于节点上环和键的查找
+func TestNewHTTPPoolOpts(t *testing.T) {
+	// 节点名和键都是数字字符串，哈希值即数字本身
+	var inputs []string
+	pool := NewHTTPPoolOpts("self", &HTTPPoolOptions{
+		Replicas: 1,
+		HashFn: func(key []byte) uint32 {
+			inputs = append(inputs, string(key))
+			i, _ := strconv.Atoi(string(key))
+			return uint32(i)
+		},
+	})
+	// 虚拟节点为 "02" "04" "06"，即哈希值 2、4、6
+	pool.Set("2", "4", "6")
+	if got := strings.Join(inputs, ","); got != "02,04,06" {
+		t.Fatalf("虚拟节点哈希输入错误: %s", got)
+	}
+	inputs = nil
+
+	testCases := map[string]string{
+		"1": "2",
+		"3": "4",
+		"5": "6",
+		"7": "2", // 超过最大哈希值，回绕到环首
+	}
+	for key, want := range testCases {
+		peer, ok := pool.PickPeer(key)
+		if !ok {
+			t.Fatalf("键 %s 未选中任何节点", key)
+		}
+		if got := peer.(*httpGetter).baseURL; got != want+defaultBasePath {
+			t.Errorf("键 %s 应命中 %s, 实际命中 %s", key, want+defaultBasePath, got)
+		}
+	}
+	if len(inputs) != len(testCases) {
+		t.Fatalf("每次查找应调用一次注入的哈希函数, 实际 %d 次", len(inputs))
+	}
+}
+
+func TestNewHTTPPoolDefaults(t *testing.T) {
+	pool := NewHTTPPool("self")
+	if pool.opts.Replicas != defaultReplicas || pool.opts.HashFn != nil {
+		t.Fatalf("默认配置错误: %+v", pool.opts)
+	}
+	if pool.client == nil || pool.client == http.DefaultClient {
+		t.Fatal("expect a dedicated http client")
+	}
+	if tr, ok := pool.client.Transport.(*http.Transport); !ok || tr.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost {
+		t.Fatalf("unexpected transport %+v", pool.client.Transport)
+	}
+}
+
+// TestHTTPPool_ClientTimeout 自定义客户端的超时应作用于访问慢节点的请求
+func TestHTTPPool_ClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	pool := NewHTTPPoolOpts("self", &HTTPPoolOptions{Client: client})
+	pool.Set(slow.URL)
+	peer, ok := pool.PickPeer("Tom")
+	if !ok {
+		t.Fatal("expect remote peer")
+	}
+	if peer.(*httpGetter).client != client {
+		t.Fatal("httpGetter should use the pool's client")
+	}
+
+	start := time.Now()
+	err := peer.Get(&pb.Request{Group: "scores", Key: "Tom"}, &pb.Response{})
+	if err == nil {
+		t.Fatal("expect timeout error from slow peer")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("slow peer did not time out in time: %v", elapsed)
+	}
+
+	// 未指定 Client 时，Timeout 作用于默认创建的客户端
+	pool = NewHTTPPoolOpts("self", &HTTPPoolOptions{Timeout: 50 * time.Millisecond})
+	pool.Set(slow.URL)
+	peer, _ = pool.PickPeer("Tom")
+	if err := peer.Get(&pb.Request{Group: "scores", Key: "Tom"}, &pb.Response{}); err == nil {
+		t.Fatal("expect timeout error with configured Timeout")
+	}
+}
+
+// TestServeHTTP_ClientCancel 请求方取消后，处理函数应及时返回而不是等待慢加载
+func TestServeHTTP_ClientCancel(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	NewGroup("slow-http", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		<-release // 模拟一直阻塞的数据源
+		return []byte(key), nil
+	}))
+
+	pool := NewHTTPPool("self")
+	returned := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pool.ServeHTTP(w, r)
+		close(returned)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+defaultBasePath+"slow-http/key", nil)
+	if _, err := http.DefaultClient.Do(req); err == nil {
+		t.Fatal("expect client error after cancel")
+	}
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("handler did not return after client cancelled")
+	}
+}
+
+type ctxKey struct{}
+
+// TestGetContext_ContextGetter 调用方上下文中的值传给数据源，加载只受 LoadTimeout 约束
+func TestGetContext_ContextGetter(t *testing.T) {
+	cancelled := make(chan error, 1)
+	g := NewGroupOpts("ctx-getter", 2<<10, ContextGetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		if ctx.Value(ctxKey{}) != "trace" {
+			t.Error("expect caller's context values to reach the getter")
+		}
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return nil, ctx.Err()
+	}), &GroupOptions{LoadTimeout: 50 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "trace"))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := g.GetContext(ctx, "key"); err != context.Canceled {
+		t.Fatalf("expect context.Canceled, got %v", err)
+	}
+	select {
+	case err := <-cancelled:
+		if err != context.DeadlineExceeded {
+			t.Fatalf("getter should be stopped by LoadTimeout, not the caller, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("getter did not observe LoadTimeout")
+	}
+}
+
+// TestGetContext_LeaderCancel 发起加载的调用方取消后，合并等待的其他调用方仍能拿到结果
+func TestGetContext_LeaderCancel(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	g := NewGroup("leader-cancel", 2<<10, ContextGetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		close(started)
+		select {
+		case <-release:
+			return []byte("value"), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}))
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := g.GetContext(leaderCtx, "key")
+		leaderErr <- err
+	}()
+	<-started
+
+	followerRes := make(chan ByteView, 1)
+	followerErr := make(chan error, 1)
+	go func() {
+		v, err := g.GetContext(context.Background(), "key")
+		followerRes <- v
+		followerErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond) // 等待第二个调用方合并到同一次加载
+	cancelLeader()
+	if err := <-leaderErr; err != context.Canceled {
+		t.Fatalf("expect leader to get context.Canceled, got %v", err)
+	}
+	close(release)
+	if v, err := <-followerRes, <-followerErr; err != nil || v.String() != "value" {
+		t.Fatalf("expect follower to get the value, got %q %v", v, err)
+	}
+}
+
+// TestReadReplicas 主节点读取失败时，从哈希环上的后继副本读取
+func TestReadReplicas(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "cold", http.StatusInternalServerError)
+	}))
+	defer primary.Close()
+	var replicaHits int
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		replicaHits++
+		body, _ := proto.Marshal(&pb.Response{Value: []byte("from-replica")})
+		w.Write(body)
+	}))
+	defer replica.Close()
+
+	// 主节点哈希为 10，副本为 20，键哈希为 5，因此键依次命中主节点、副本
+	hashFn := func(data []byte) uint32 {
+		switch {
+		case strings.HasSuffix(string(data), primary.URL):
+			return 10
+		case strings.HasSuffix(string(data), replica.URL):
+			return 20
+		}
+		return 5
+	}
+	pool := NewHTTPPoolOpts("self", &HTTPPoolOptions{Replicas: 1, HashFn: hashFn, ReadReplicas: 1})
+	pool.Set(primary.URL, replica.URL)
+	if peers := pool.PickPeers("Tom"); len(peers) != 2 {
+		t.Fatalf("expect primary and one replica, got %d peers", len(peers))
+	}
+
+	var loads int
+	gee := NewGroup("replica-read", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		loads++
+		return []byte("local"), nil
+	}))
+	gee.RegisterPeers(pool)
+	if view, err := gee.Get("Tom"); err != nil || view.String() != "from-replica" {
+		t.Fatalf("expect value from replica, got %s %v", view, err)
+	}
+	if replicaHits != 1 || loads != 0 {
+		t.Fatalf("expect one replica hit and no local load, got %d %d", replicaHits, loads)
+	}
+
+	// 不配置副本时只访问主节点，失败后回退到本地
+	single := NewHTTPPoolOpts("self", &HTTPPoolOptions{Replicas: 1, HashFn: hashFn})
+	single.Set(primary.URL, replica.URL)
+	if peers := single.PickPeers("Tom"); len(peers) != 1 {
+		t.Fatalf("expect only primary, got %d peers", len(peers))
+	}
+}
+
+// TestHealthz 健康检查返回 200 和节点统计信息
+func TestHealthz(t *testing.T) {
+	g := NewGroup("health", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}))
+	g.Get("Tom")
+	g.Get("Tom") // 第二次命中本地缓存
+
+	pool := NewHTTPPool("self")
+	for _, path := range []string{"/healthz", "/readyz"} {
+		w := httptest.NewRecorder()
+		pool.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("%s: unexpected response %d %s", path, w.Code, w.Body.String())
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &fields); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"status", "groups", "gets", "hits", "hit_ratio"} {
+			if _, ok := fields[key]; !ok {
+				t.Fatalf("%s: missing field %s in %s", path, key, w.Body.String())
+			}
+		}
+		if fields["status"] != "ok" || fields["groups"].(float64) < 1 || fields["hits"].(float64) < 1 {
+			t.Fatalf("%s: unexpected stats %v", path, fields)
+		}
+	}
+	if stats := g.Stats(); stats.Gets != 2 || stats.Hits != 1 {
+		t.Fatalf("unexpected group stats %+v", stats)
+	}
+}
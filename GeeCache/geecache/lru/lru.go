@@ -1,11 +1,15 @@
 ﻿package lru
 
-import "container/list"
+import (
+	"container/list"
+	"sync/atomic"
+)
 
 // Cache 是一个LRU（最近最少使用）缓存结构。
 // 当缓存达到最大容量时，会自动淘汰最久未使用的项目。
 type Cache struct {
 	maxBytes  int64                         // 缓存的最大容量（以字节为单位），0表示无限制
+	maxItems  int64                         // 缓存的最大项目数量，0表示无限制
 	nbytes    int64                         // 当前缓存已使用的总字节数（包括键和值）
 	ll        *list.List                    // 双向链表，用于实现LRU策略，链表头是最近使用的元素
 	cache     map[string]*list.Element      // 哈希表，用于存储键到链表元素的映射
@@ -14,8 +18,9 @@ type Cache struct {
 
 // entry 是链表节点中存储的数据结构
 type entry struct {
-	key   string // 缓存的键
-	value Value  // 缓存的值
+	key     string // 缓存的键
+	value   Value  // 缓存的值
+	visited uint32 // Peek 访问标记（原子读写），淘汰时给予一次"第二次机会"
 }
 
 // Value 是缓存值必须实现的接口
@@ -27,8 +32,17 @@ type Value interface {
 // maxBytes: 缓存的最大容量（字节），0表示无限制
 // onEvicted: 淘汰项目时的回调函数（可为nil）
 func New(maxBytes int64, onEvicted func(string, Value)) *Cache {
+	return NewWithLimits(maxBytes, 0, onEvicted)
+}
+
+// NewWithLimits 创建同时限制容量和项目数量的LRU缓存实例
+// maxBytes: 缓存的最大容量（字节），0表示无限制
+// maxItems: 缓存的最大项目数量，0表示无限制
+// onEvicted: 淘汰项目时的回调函数（可为nil）
+func NewWithLimits(maxBytes, maxItems int64, onEvicted func(string, Value)) *Cache {
 	return &Cache{
 		maxBytes:  maxBytes,
+		maxItems:  maxItems,
 		ll:        list.New(),
 		cache:     make(map[string]*list.Element),
 		OnEvicted: onEvicted,
@@ -47,9 +61,34 @@ func (c *Cache) Get(key string) (value Value, ok bool) {
 	return nil, false
 }
 
+// Peek 获取键对应的值但不移动链表，只原子地标记该项目被访问过，
+// 因此多个 goroutine 可以在读锁下并发调用(但不能与 Add 等写操作并发)。
+// 被标记的项目在淘汰时会被移到链表头部而不是淘汰，从而近似LRU
+func (c *Cache) Peek(key string) (value Value, ok bool) {
+	if ele, exists := c.cache[key]; exists {
+		kv := ele.Value.(*entry)
+		if atomic.LoadUint32(&kv.visited) == 0 {
+			atomic.StoreUint32(&kv.visited, 1)
+		}
+		return kv.value, true
+	}
+	return nil, false
+}
+
 // RemoveOldest 淘汰链表尾部的项目（最久未使用）
+// 尾部项目被 Peek 访问过时清除标记并移到链表头部，继续检查新的尾部
 func (c *Cache) RemoveOldest() {
 	ele := c.ll.Back() // 获取链表尾部元素（最久未使用）
+	// 最多检查一轮，所有标记清除后必然能淘汰一个项目
+	for i := c.ll.Len(); i > 0 && ele != nil; i-- {
+		kv := ele.Value.(*entry)
+		if atomic.LoadUint32(&kv.visited) == 0 {
+			break
+		}
+		atomic.StoreUint32(&kv.visited, 0)
+		c.ll.MoveToFront(ele)
+		ele = c.ll.Back()
+	}
 	if ele != nil {
 		c.ll.Remove(ele)                                       // 从链表中移除
 		kv := ele.Value.(*entry)                               // 获取节点数据
@@ -61,10 +100,26 @@ func (c *Cache) RemoveOldest() {
 	}
 }
 
+// Remove 删除指定键，返回键是否存在；设置了 OnEvicted 时同样会回调
+func (c *Cache) Remove(key string) bool {
+	ele, exists := c.cache[key]
+	if !exists {
+		return false
+	}
+	c.ll.Remove(ele)
+	kv := ele.Value.(*entry)
+	delete(c.cache, kv.key)
+	c.nbytes -= int64(len(kv.key)) + int64(kv.value.Len())
+	if c.OnEvicted != nil {
+		c.OnEvicted(kv.key, kv.value)
+	}
+	return true
+}
+
 // Add 向缓存中添加/更新键值对
 // 如果键已存在：更新值并将项目移到链表头部
 // 如果键不存在：在链表头部添加新项目，并更新内存计数
-// 添加后如果超过最大内存，则循环淘汰最久未使用的项目直到满足容量限制
+// 添加后如果超过最大内存或最大项目数，则循环淘汰最久未使用的项目直到满足限制
 func (c *Cache) Add(key string, value Value) {
 	if ele, exists := c.cache[key]; exists { // 键已存在
 		c.ll.MoveToFront(ele)    // 移动到链表头部
@@ -73,14 +128,15 @@ func (c *Cache) Add(key string, value Value) {
 		c.nbytes += int64(value.Len()) - int64(kv.value.Len())
 		kv.value = value // 更新值
 	} else { // 新键
-		ele := c.ll.PushFront(&entry{key, value}) // 在链表头部插入新节点
-		c.cache[key] = ele                        // 添加到哈希表
+		ele := c.ll.PushFront(&entry{key: key, value: value}) // 在链表头部插入新节点
+		c.cache[key] = ele                                    // 添加到哈希表
 		// 增加内存：键长 + 值大小
 		c.nbytes += int64(len(key)) + int64(value.Len())
 	}
 
-	// 如果设置了最大内存（非0）且当前内存超出，则循环淘汰
-	for c.maxBytes != 0 && c.nbytes > c.maxBytes {
+	// 如果设置了最大内存或最大项目数（非0）且当前超出，则循环淘汰
+	for (c.maxBytes != 0 && c.nbytes > c.maxBytes) ||
+		(c.maxItems != 0 && int64(c.ll.Len()) > c.maxItems) {
 		c.RemoveOldest()
 	}
 }
@@ -89,3 +145,25 @@ func (c *Cache) Add(key string, value Value) {
 func (c *Cache) Len() int {
 	return c.ll.Len()
 }
+
+// Keys 返回缓存中的所有键，按最近使用到最久未使用排序
+// 不会改变项目的使用顺序
+func (c *Cache) Keys() []string {
+	keys := make([]string, 0, c.ll.Len())
+	c.Range(func(key string, _ Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Range 从最近使用到最久未使用依次遍历缓存项目，fn 返回 false 时停止遍历
+// 不会改变项目的使用顺序，遍历期间不能修改缓存
+func (c *Cache) Range(fn func(key string, value Value) bool) {
+	for ele := c.ll.Front(); ele != nil; ele = ele.Next() {
+		kv := ele.Value.(*entry)
+		if !fn(kv.key, kv.value) {
+			return
+		}
+	}
+}
@@ -69,3 +69,82 @@ func TestOnEvicted(t *testing.T) {
 		t.Fatalf("Call OnEvicted failed, expect keys %v but got %v", expect, keys)
 	}
 }
+
+func TestKeysAndRange(t *testing.T) {
+	lru := New(int64(0), nil)
+	lru.Add("k1", String("v1"))
+	lru.Add("k2", String("v2"))
+	lru.Add("k3", String("v3"))
+	lru.Get("k1")
+
+	expect := []string{"k1", "k3", "k2"}
+	if keys := lru.Keys(); !reflect.DeepEqual(keys, expect) {
+		t.Fatalf("Keys failed, expect keys equals to %s, got %s", expect, keys)
+	}
+
+	var visited []string
+	lru.Range(func(key string, value Value) bool {
+		visited = append(visited, key+"="+string(value.(String)))
+		return len(visited) < 2
+	})
+	if expect := []string{"k1=v1", "k3=v3"}; !reflect.DeepEqual(visited, expect) {
+		t.Fatalf("Range failed, expect %s, got %s", expect, visited)
+	}
+
+	// 遍历不应改变使用顺序
+	if keys := lru.Keys(); !reflect.DeepEqual(keys, expect) {
+		t.Fatalf("Range changed recency order: %s", keys)
+	}
+}
+
+func TestMaxItems(t *testing.T) {
+	keys := make([]string, 0)
+	callback := func(key string, value Value) {
+		keys = append(keys, key)
+	}
+	lru := NewWithLimits(int64(1<<20), 2, callback) // 容量充足，仅受项目数量限制
+	lru.Add("k1", String("v1"))
+	lru.Add("k2", String("v2"))
+	lru.Add("k3", String("v3"))
+
+	if _, ok := lru.Get("k1"); ok || lru.Len() != 2 {
+		t.Fatalf("Max items failed: len %d", lru.Len())
+	}
+	if expect := []string{"k1"}; !reflect.DeepEqual(expect, keys) {
+		t.Fatalf("Call OnEvicted failed, expect keys equals to %s, got %s", expect, keys)
+	}
+}
+
+// TestRemove 测试删除指定键并释放容量
+func TestRemove(t *testing.T) {
+	lru := New(int64(0), nil)
+	lru.Add("key1", String("1234"))
+	lru.Add("key2", String("5678"))
+	if !lru.Remove("key1") || lru.Remove("key1") {
+		t.Fatalf("Remove key1 should succeed exactly once")
+	}
+	if _, ok := lru.Get("key1"); ok || lru.Len() != 1 || lru.nbytes != int64(len("key2")+len("5678")) {
+		t.Fatalf("Remove key1 failed, len=%d nbytes=%d", lru.Len(), lru.nbytes)
+	}
+}
+
+// TestPeekSecondChance 测试 Peek 不移动链表，但被访问过的项目在淘汰时获得第二次机会
+func TestPeekSecondChance(t *testing.T) {
+	lru := NewWithLimits(0, 3, nil)
+	lru.Add("k1", String("1"))
+	lru.Add("k2", String("2"))
+	lru.Add("k3", String("3"))
+	if v, ok := lru.Peek("k1"); !ok || v.(String) != "1" {
+		t.Fatalf("Peek k1 failed")
+	}
+	if keys := lru.Keys(); !reflect.DeepEqual(keys, []string{"k3", "k2", "k1"}) {
+		t.Fatalf("Peek should not change order, got %v", keys)
+	}
+	lru.Add("k4", String("4")) // k1 被访问过，淘汰 k2
+	if _, ok := lru.Peek("k2"); ok {
+		t.Fatalf("expect k2 evicted, keys %v", lru.Keys())
+	}
+	if keys := lru.Keys(); !reflect.DeepEqual(keys, []string{"k1", "k4", "k3"}) {
+		t.Fatalf("expect visited k1 moved to front, got %v", keys)
+	}
+}
@@ -0,0 +1,41 @@
+﻿package lru
+
+import (
+	"time"
+	"unsafe"
+)
+
+// 常用非字节类型的 Value 实现，使 Cache 可以脱离 Group 直接缓存计算结果
+
+// 编译时检查
+var (
+	_ Value = IntValue(0)
+	_ Value = StringValue("")
+	_ Value = TimeValue{}
+)
+
+// IntValue 缓存整数，固定占用 8 字节
+type IntValue int64
+
+// Len 返回 int64 的大小
+func (v IntValue) Len() int {
+	return int(unsafe.Sizeof(v))
+}
+
+// StringValue 缓存字符串，占用字节数等于字符串长度
+type StringValue string
+
+// Len 返回字符串的字节长度
+func (v StringValue) Len() int {
+	return len(v)
+}
+
+// TimeValue 缓存时间点，占用 time.Time 结构体的大小
+type TimeValue struct {
+	time.Time
+}
+
+// Len 返回 time.Time 的大小
+func (v TimeValue) Len() int {
+	return int(unsafe.Sizeof(v.Time))
+}
@@ -0,0 +1,50 @@
+﻿package lru
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// TestValueWrappers 各包装类型的字节统计与读取
+func TestValueWrappers(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		key   string
+		value Value
+		size  int
+	}{
+		{"int", IntValue(42), 8},
+		{"string", StringValue("hello"), 5},
+		{"time", TimeValue{now}, int(unsafe.Sizeof(now))},
+	}
+
+	c := New(0, nil)
+	var total int64
+	for _, tc := range cases {
+		if tc.value.Len() != tc.size {
+			t.Fatalf("%s: expect Len %d, got %d", tc.key, tc.size, tc.value.Len())
+		}
+		c.Add(tc.key, tc.value)
+		total += int64(len(tc.key) + tc.size)
+		if c.nbytes != total {
+			t.Fatalf("%s: expect %d bytes in cache, got %d", tc.key, total, c.nbytes)
+		}
+	}
+
+	if v, ok := c.Get("int"); !ok || v.(IntValue) != 42 {
+		t.Fatalf("get int failed: %v", v)
+	}
+	if v, ok := c.Get("string"); !ok || v.(StringValue) != "hello" {
+		t.Fatalf("get string failed: %v", v)
+	}
+	if v, ok := c.Get("time"); !ok || !v.(TimeValue).Equal(now) {
+		t.Fatalf("get time failed: %v", v)
+	}
+
+	// 覆盖写入按新旧值的大小差调整统计
+	c.Add("string", StringValue("hi"))
+	if c.nbytes != total-3 {
+		t.Fatalf("expect %d bytes after update, got %d", total-3, c.nbytes)
+	}
+}
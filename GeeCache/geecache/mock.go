@@ -0,0 +1,103 @@
+﻿package geecache
+
+import (
+	"sync"
+
+	pb "github.com/nukecoke1828/7daysProgram/GeeCache/geecache/geecachepb"
+)
+
+// 编译期断言
+var (
+	_ PeerGetter = (*MockPeerGetter)(nil) // 确保 *MockPeerGetter 实现了 PeerGetter 接口
+	_ PeerPicker = (*LoopbackPicker)(nil) // 确保 *LoopbackPicker 实现了 PeerPicker 接口
+	_ PeerGetter = loopbackGetter(nil)    // 确保 loopbackGetter 实现了 PeerGetter 接口
+)
+
+// StaticGetter 返回从固定键值表读取数据的 Getter，供测试和压测使用
+// 键不存在时返回 ErrNotFound；values 会被复制，之后的修改不影响返回的 Getter
+func StaticGetter(values map[string][]byte) Getter {
+	data := copyValues(values)
+	return GetterFunc(func(key string) ([]byte, error) {
+		v, ok := data[key]
+		if !ok {
+			return nil, ErrNotFound
+		}
+		return cloneBytes(v), nil
+	})
+}
+
+// LoopbackPicker 是供测试和压测使用的 PeerPicker，不经过 HTTP
+// values 中的键视为属于远程节点，直接从内存返回；其余键由本节点加载
+// 从远程节点取到的值不会写入本地缓存，因此每次访问远程键都是一次未命中
+type LoopbackPicker struct {
+	peer loopbackGetter
+}
+
+// NewLoopbackPicker 创建以 values 作为远程节点数据的 LoopbackPicker，values 会被复制
+func NewLoopbackPicker(values map[string][]byte) *LoopbackPicker {
+	return &LoopbackPicker{peer: copyValues(values)}
+}
+
+// PickPeer 实现 PeerPicker 接口
+func (p *LoopbackPicker) PickPeer(key string) (PeerGetter, bool) {
+	if _, ok := p.peer[key]; !ok {
+		return nil, false
+	}
+	return p.peer, true
+}
+
+// loopbackGetter 只读的内存远程节点，并发读取无需加锁
+type loopbackGetter map[string][]byte
+
+// Get 实现 PeerGetter 接口
+func (l loopbackGetter) Get(in *pb.Request, out *pb.Response) error {
+	v, ok := l[in.Key]
+	if !ok {
+		return ErrNotFound
+	}
+	out.Value = cloneBytes(v)
+	return nil
+}
+
+// copyValues 复制键值表，避免调用方后续修改影响数据
+func copyValues(values map[string][]byte) map[string][]byte {
+	data := make(map[string][]byte, len(values))
+	for k, v := range values {
+		data[k] = cloneBytes(v)
+	}
+	return data
+}
+
+// MockPeerGetter 是供测试使用的内存远程节点，从固定的键值表中返回数据，无需网络
+// 键不存在时返回 ErrNotFound；Err 非 nil 时所有请求都返回该错误
+type MockPeerGetter struct {
+	mu       sync.Mutex
+	values   map[string][]byte
+	Err      error         // 注入的错误
+	Requests []*pb.Request // 收到的请求，按到达顺序记录
+}
+
+// NewMockPeerGetter 创建基于键值表的 MockPeerGetter，values 会被复制
+func NewMockPeerGetter(values map[string]string) *MockPeerGetter {
+	m := &MockPeerGetter{values: make(map[string][]byte, len(values))}
+	for k, v := range values {
+		m.values[k] = []byte(v)
+	}
+	return m
+}
+
+// Get 实现 PeerGetter 接口
+func (m *MockPeerGetter) Get(in *pb.Request, out *pb.Response) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Requests = append(m.Requests, in)
+	if m.Err != nil {
+		return m.Err
+	}
+	v, ok := m.values[in.Key]
+	if !ok {
+		return ErrNotFound
+	}
+	out.Value = cloneBytes(v)
+	return nil
+}
@@ -14,6 +14,12 @@ type PeerPicker interface {
 	PickPeer(key string) (peer PeerGetter, ok bool)
 }
 
+// MultiPeerPicker 可选接口，为一个键返回按优先级排列的多个候选节点(主节点及其副本)
+// Group 加载时依次尝试，前一个失败才请求下一个；返回空表示应由本节点加载
+type MultiPeerPicker interface {
+	PickPeers(key string) []PeerGetter
+}
+
 // PeerGetter 接口定义了从远程节点获取缓存值的行为
 // 用于与缓存集群中的其他节点进行通信
 type PeerGetter interface {
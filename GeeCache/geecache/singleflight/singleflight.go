@@ -4,9 +4,10 @@ import "sync"
 
 // call 代表一个正在进行中或已完成的函数调用
 type call struct {
-	wg  sync.WaitGroup // 用于阻塞等待的同步原语
-	val interface{}    // 函数调用返回的结果值
-	err error          // 函数调用返回的错误
+	wg   sync.WaitGroup // 用于阻塞等待的同步原语
+	val  interface{}    // 函数调用返回的结果值
+	err  error          // 函数调用返回的错误
+	dups int            // 等待该调用结果的重复请求数
 }
 
 // Group 管理不同键(key)的函数调用
@@ -17,12 +18,16 @@ type Group struct {
 
 // Do 确保对于给定键的函数调用只执行一次
 // 参数:
-//   key - 调用的唯一标识符
-//   fn - 实际执行的函数，返回值和错误
+//
+//	key - 调用的唯一标识符
+//	fn - 实际执行的函数，返回值和错误
+//
 // 返回值:
-//   interface{} - 函数调用的结果
-//   error - 函数调用的错误
-func (g *Group) Do(key string, fn func() (interface{}, error)) (interface{}, error) {
+//
+//	interface{} - 函数调用的结果
+//	error - 函数调用的错误
+//	bool - 结果是否被多个调用方共享（有重复请求合并到这次调用时为 true）
+func (g *Group) Do(key string, fn func() (interface{}, error)) (interface{}, error, bool) {
 	g.mu.Lock()
 
 	// 延迟初始化map
@@ -32,9 +37,10 @@ func (g *Group) Do(key string, fn func() (interface{}, error)) (interface{}, err
 
 	// 如果该键的调用已存在
 	if c, ok := g.m[key]; ok {
-		g.mu.Unlock()       // 解锁让其他请求可以进入
-		c.wg.Wait()         // 等待该调用完成
-		return c.val, c.err // 返回共享的结果
+		c.dups++                  // 在锁内登记，保证执行者能看到
+		g.mu.Unlock()             // 解锁让其他请求可以进入
+		c.wg.Wait()               // 等待该调用完成
+		return c.val, c.err, true // 返回共享的结果
 	}
 
 	// 创建新的调用
@@ -50,7 +56,8 @@ func (g *Group) Do(key string, fn func() (interface{}, error)) (interface{}, err
 	// 清理调用记录
 	g.mu.Lock()
 	delete(g.m, key) // 从映射表中删除
+	shared := c.dups > 0
 	g.mu.Unlock()
 
-	return c.val, c.err
+	return c.val, c.err, shared
 }
@@ -0,0 +1,39 @@
+﻿package geecache
+
+import (
+	"encoding/gob"
+	"io"
+)
+
+// snapshotEntry 快照中的单个缓存项
+type snapshotEntry struct {
+	Key   string
+	Value []byte
+}
+
+// Snapshot 将本地主缓存中的键值对以gob编码写入w
+// 写入顺序为最近使用到最久未使用，Restore 时据此恢复使用顺序
+func (g *Group) Snapshot(w io.Writer) error {
+	var entries []snapshotEntry
+	g.mainCache.rangeAll(func(key string, value ByteView) bool {
+		entries = append(entries, snapshotEntry{Key: key, Value: value.ByteSlice()})
+		return true
+	})
+	return gob.NewEncoder(w).Encode(entries)
+}
+
+// Restore 从r读取Snapshot写出的快照，并填充到本地主缓存
+// 已存在的键会被快照中的值覆盖
+func (g *Group) Restore(r io.Reader) error {
+	var entries []snapshotEntry
+	if err := gob.NewDecoder(r).Decode(&entries); err != nil {
+		return err
+	}
+	// 从最久未使用的项开始写入，使最近使用的项最后加入、位于链表头部
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		g.negative.remove(e.Key)
+		g.populateCache(e.Key, ByteView{b: e.Value})
+	}
+	return nil
+}
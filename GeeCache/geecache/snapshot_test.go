@@ -0,0 +1,43 @@
+﻿package geecache
+
+import (
+	"bytes"
+	"sync/atomic"
+	"testing"
+)
+
+// TestSnapshotRestore 测试快照恢复到新的缓存组后直接命中缓存
+func TestSnapshotRestore(t *testing.T) {
+	src := NewGroup("snapshot-src", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte(db[key]), nil
+		}))
+	for k := range db {
+		if _, err := src.Get(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := src.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var loads int32
+	dst := NewGroup("snapshot-dst", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			atomic.AddInt32(&loads, 1)
+			return nil, ErrNotFound
+		}))
+	if err := dst.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range db {
+		if view, err := dst.Get(k); err != nil || view.String() != v {
+			t.Fatalf("Failed to get value of %s: expected %s, got %s %v", k, v, view, err)
+		}
+	}
+	if n := atomic.LoadInt32(&loads); n != 0 {
+		t.Fatalf("restored keys should be served from cache, getter ran %d times", n)
+	}
+}
@@ -32,6 +32,22 @@ func (c *Clause) Set(name Type, vars ...interface{}) {
 	c.sqlVars[name] = vars
 }
 
+// 直接设置子句的SQL片段及参数，用于由方言生成、通用生成器无法表达的子句
+func (c *Clause) SetSQL(name Type, sql string, vars ...interface{}) {
+	if c.sql == nil { // 初始化
+		c.sql = make(map[Type]string)
+		c.sqlVars = make(map[Type][]interface{})
+	}
+	c.sql[name] = sql
+	c.sqlVars[name] = vars
+}
+
+// 移除已设置的子句，未设置时什么也不做
+func (c *Clause) Remove(name Type) {
+	delete(c.sql, name)
+	delete(c.sqlVars, name)
+}
+
 // 根据Type的顺序构造最终的SQL语句
 func (c *Clause) Build(orders ...Type) (string, []interface{}) {
 	var sqls []string              // 存储各个子句的SQL片段
@@ -44,3 +60,9 @@ func (c *Clause) Build(orders ...Type) (string, []interface{}) {
 	}
 	return strings.Join(sqls, " "), vars // 合并SQL片段并返回
 }
+
+// 判断是否已设置某个子句
+func (c *Clause) Has(name Type) bool {
+	_, ok := c.sql[name]
+	return ok
+}
@@ -21,8 +21,39 @@ func testSelect(t *testing.T) {
 	}
 }
 
+func testSelectDistinct(t *testing.T) {
+	var clause Clause
+	clause.Set(SELECT, "User", []string{"Age"}, true) // SELECT DISTINCT Age FROM User
+	clause.Set(WHERE, "Age > ?", 10)
+	sql, vars := clause.Build(SELECT, WHERE)
+	if sql != "SELECT DISTINCT Age FROM User WHERE Age > ?" || !reflect.DeepEqual(vars, []interface{}{10}) {
+		t.Fatal("failed to build distinct SQL, got", sql)
+	}
+}
+
+func TestClause_SetSQLAndRemove(t *testing.T) {
+	var clause Clause
+	clause.Set(SELECT, "User", []string{"*"})
+	clause.SetSQL(LIMIT, "LIMIT -1 OFFSET ?", 5)
+	sql, vars := clause.Build(SELECT, LIMIT)
+	if sql != "SELECT * FROM User LIMIT -1 OFFSET ?" || !reflect.DeepEqual(vars, []interface{}{5}) {
+		t.Fatal("failed to build raw clause, got", sql, vars)
+	}
+	clause.Remove(LIMIT)
+	if clause.Has(LIMIT) {
+		t.Fatal("expect LIMIT removed")
+	}
+	sql, vars = clause.Build(SELECT, LIMIT)
+	if sql != "SELECT * FROM User" || len(vars) != 0 {
+		t.Fatal("removed clause should not be built, got", sql, vars)
+	}
+}
+
 func TestClause_Build(t *testing.T) {
 	t.Run("select", func(t *testing.T) { // 启动子测试select
 		testSelect(t)
 	})
+	t.Run("distinct", func(t *testing.T) {
+		testSelectDistinct(t)
+	})
 }
@@ -61,14 +61,24 @@ func _values(values ...interface{}) (string, []interface{}) {
 }
 
 // 查找语句
+// 输入
+// 1.表名
+// 2.字段列表
+// 3.是否去重(可选)
 func _select(values ...interface{}) (string, []interface{}) {
 	tableName := values[0]
 	fields := strings.Join(values[1].([]string), ", ")
+	if len(values) > 2 && values[2].(bool) {
+		return fmt.Sprintf("SELECT DISTINCT %v FROM %s", fields, tableName), []interface{}{}
+	}
 	return fmt.Sprintf("SELECT %v FROM %s", fields, tableName), []interface{}{}
 }
 
 // 限制查询结果数量
 func _limit(values ...interface{}) (string, []interface{}) {
+	if len(values) > 1 { // 第二个参数为偏移量
+		return "LIMIT ? OFFSET ?", values
+	}
 	return "LIMIT ?", values
 }
 
@@ -102,7 +112,12 @@ func _delete(values ...interface{}) (string, []interface{}) {
 }
 
 // 统计满足条件的记录数量
+// 可选的第二个参数为聚合表达式，如 "DISTINCT Name"，缺省为 "*"
 func _count(values ...interface{}) (string, []interface{}) {
-	// 替换为SELECT COUNT(*) FROM tableName
-	return _select(values[0], []string{"COUNT(*)"})
+	expr := "*"
+	if len(values) > 1 {
+		expr = values[1].(string)
+	}
+	// 替换为SELECT COUNT(expr) FROM tableName
+	return _select(values[0], []string{fmt.Sprintf("COUNT(%s)", expr)})
 }
@@ -2,6 +2,7 @@
 
 import (
 	"reflect"
+	"strings"
 )
 
 var dialectsMap = map[string]Dialect{}
@@ -11,6 +12,70 @@ type Dialect interface {
 	TableExistSQL(tableName string) (string, []interface{}) // 检查表是否存在的SQL语句及参数
 }
 
+// ReadOnlyDialect 可选接口：驱动忽略 sql.TxOptions.ReadOnly 时，
+// 由方言提供在事务连接上开启/关闭只读模式的SQL语句
+type ReadOnlyDialect interface {
+	ReadOnlySQL(readOnly bool) string
+}
+
+// IndexDialect 可选接口：由方言提供建索引的SQL语句，未实现时使用标准的 CREATE [UNIQUE] INDEX 语句
+type IndexDialect interface {
+	CreateIndexSQL(tableName, indexName, column string, unique bool) string
+}
+
+// ConstraintDialect 可选接口：由方言拼写字段约束（NOT NULL、UNIQUE、DEFAULT），
+// 返回追加在字段类型之后的部分，未实现时使用标准写法 "NOT NULL UNIQUE DEFAULT 值"
+type ConstraintDialect interface {
+	ColumnConstraintSQL(notNull, unique bool, defaultValue string) string
+}
+
+// OffsetDialect 可选接口：由方言提供只设置偏移量、不限制行数的子句，含一个 OFFSET 参数占位符，
+// 如 SQLite 的 "LIMIT -1 OFFSET ?"，未实现时使用标准写法 "OFFSET ?"
+type OffsetDialect interface {
+	OffsetOnlySQL() string
+}
+
+// SavepointDialect 可选接口：由方言提供保存点相关的SQL语句，
+// 未实现时使用标准的 SAVEPOINT / ROLLBACK TO SAVEPOINT / RELEASE SAVEPOINT（SQLite、MySQL 均支持）
+type SavepointDialect interface {
+	SavepointSQL(name string) string
+	RollbackToSQL(name string) string
+	ReleaseSavepointSQL(name string) string
+}
+
+// ColumnTypeDialect 可选接口：由方言提供查询表中现有字段类型的SQL语句，
+// 查询结果每行为 (字段名, 字段类型)，Migrate 据此发现字段类型的变化，
+// 再按 AlterColumnTypesSQL 返回的语句依次执行完成修改，须保留字段约束与索引
+type ColumnTypeDialect interface {
+	ColumnTypesSQL(tableName string) (string, []interface{})
+	AlterColumnTypesSQL(change ColumnTypeChange) []string
+}
+
+// ColumnTypeChange 描述一张表的字段类型变化，由 Migrate 根据结构体生成
+type ColumnTypeChange struct {
+	Table       string            // 表名
+	Columns     []string          // 表的全部字段名，顺序与结构体一致
+	Definitions []string          // 与 Columns 一一对应的完整字段定义，如 "Age integer NOT NULL DEFAULT 0"
+	Changed     map[string]string // 类型发生变化的字段名 -> 新类型
+	Indexes     []string          // 结构体声明的建索引语句，重建表后需要恢复
+}
+
+// StandardConstraintSQL 以标准 SQL 拼写字段约束，如 "NOT NULL UNIQUE DEFAULT 0"，
+// 供未实现 ConstraintDialect 的方言回退使用
+func StandardConstraintSQL(notNull, unique bool, defaultValue string) string {
+	var parts []string
+	if notNull {
+		parts = append(parts, "NOT NULL")
+	}
+	if unique {
+		parts = append(parts, "UNIQUE")
+	}
+	if defaultValue != "" {
+		parts = append(parts, "DEFAULT "+defaultValue)
+	}
+	return strings.Join(parts, " ")
+}
+
 // RegisterDialect 注册一个数据库方言
 func RegisterDialect(name string, dialect Dialect) {
 	dialectsMap[name] = dialect
@@ -3,12 +3,18 @@
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 )
 
 type sqlite3 struct{}
 
-var _ Dialect = (*sqlite3)(nil) // 确保 sqlite3 实现了 Dialect 接口（编译时检查）
+var _ Dialect = (*sqlite3)(nil)           // 确保 sqlite3 实现了 Dialect 接口（编译时检查）
+var _ ReadOnlyDialect = (*sqlite3)(nil)   // 确保 sqlite3 实现了 ReadOnlyDialect 接口
+var _ IndexDialect = (*sqlite3)(nil)      // 确保 sqlite3 实现了 IndexDialect 接口
+var _ ColumnTypeDialect = (*sqlite3)(nil) // 确保 sqlite3 实现了 ColumnTypeDialect 接口
+var _ ConstraintDialect = (*sqlite3)(nil) // 确保 sqlite3 实现了 ConstraintDialect 接口
+var _ OffsetDialect = (*sqlite3)(nil)     // 确保 sqlite3 实现了 OffsetDialect 接口
 
 func init() {
 	RegisterDialect("sqlite3", &sqlite3{})
@@ -43,3 +49,56 @@ func (s *sqlite3) TableExistSQL(tableName string) (string, []interface{}) {
 	args := []interface{}{tableName}
 	return "SELECT name FROM sqlite_master WHERE type='table' and name = ?", args
 }
+
+// ReadOnlySQL go-sqlite3 会忽略只读事务选项，通过 query_only 让当前连接拒绝写操作
+func (s *sqlite3) ReadOnlySQL(readOnly bool) string {
+	if readOnly {
+		return "PRAGMA query_only = ON"
+	}
+	return "PRAGMA query_only = OFF"
+}
+
+// CreateIndexSQL 返回建索引的 SQL 语句，索引已存在时跳过
+func (s *sqlite3) CreateIndexSQL(tableName, indexName, column string, unique bool) string {
+	if unique {
+		return fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s);", indexName, tableName, column)
+	}
+	return fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s);", indexName, tableName, column)
+}
+
+// ColumnConstraintSQL 返回字段约束，SQLite 使用标准写法，默认值按 tag 原样输出
+func (s *sqlite3) ColumnConstraintSQL(notNull, unique bool, defaultValue string) string {
+	return StandardConstraintSQL(notNull, unique, defaultValue)
+}
+
+// OffsetOnlySQL SQLite 的 OFFSET 必须跟在 LIMIT 之后，LIMIT -1 表示不限制行数
+func (s *sqlite3) OffsetOnlySQL() string {
+	return "LIMIT -1 OFFSET ?"
+}
+
+// ColumnTypesSQL 返回查询表字段名及声明类型的 SQL 语句
+func (s *sqlite3) ColumnTypesSQL(tableName string) (string, []interface{}) {
+	return "SELECT name, type FROM pragma_table_info(?)", []interface{}{tableName}
+}
+
+// AlterColumnTypesSQL SQLite 不支持修改字段类型，按完整的字段定义重建表：
+// 建临时表、按新类型 CAST 复制数据、删除原表、临时表改回原名，最后恢复索引，
+// 主键、NOT NULL、DEFAULT、UNIQUE 等约束随字段定义一并保留
+func (s *sqlite3) AlterColumnTypesSQL(change ColumnTypeChange) []string {
+	tmp := "tmp_" + change.Table
+	values := make([]string, 0, len(change.Columns))
+	for _, column := range change.Columns {
+		if typ, ok := change.Changed[column]; ok {
+			values = append(values, fmt.Sprintf("CAST(%s AS %s)", column, typ))
+		} else {
+			values = append(values, column)
+		}
+	}
+	sqls := []string{
+		fmt.Sprintf("CREATE TABLE %s (%s);", tmp, strings.Join(change.Definitions, ", ")),
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s;", tmp, strings.Join(change.Columns, ", "), strings.Join(values, ", "), change.Table),
+		fmt.Sprintf("DROP TABLE %s;", change.Table), // 原表的索引随表一起删除
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", tmp, change.Table),
+	}
+	return append(sqls, change.Indexes...)
+}
@@ -15,6 +15,8 @@ type TxFunc func(*session.Session) (interface{}, error) // 事务处理函数类
 type Engine struct { // 与用户交互的接口
 	db      *sql.DB
 	dialect dialect.Dialect
+	stmts   *session.StmtCache // 预编译语句缓存，调用 EnablePrepareStmt 后开启
+	logCfg  *session.LogConfig // SQL 日志配置，为 nil 时记录全部语句
 }
 
 func NewEngine(driver, source string) (e *Engine, err error) {
@@ -38,20 +40,47 @@ func NewEngine(driver, source string) (e *Engine, err error) {
 }
 
 func (engine *Engine) Close() {
+	if engine.stmts != nil {
+		_ = engine.stmts.Close() // 先关闭预编译语句，再关闭连接池
+	}
 	if err := engine.db.Close(); err != nil {
 		log.Error("Failed to close database")
 	}
 	log.Info("Close database success")
 }
 
+// EnablePrepareStmt 开启预编译语句缓存，之后创建的会话在事务外会复用相同SQL的预编译语句
+// 需在创建会话之前调用
+func (engine *Engine) EnablePrepareStmt() *Engine {
+	if engine.stmts == nil {
+		engine.stmts = session.NewStmtCache(engine.db)
+	}
+	return engine
+}
+
+// SetLogConfig 设置 SQL 日志配置(开关、慢查询阈值、输出)，对之后创建的会话生效
+func (engine *Engine) SetLogConfig(cfg *session.LogConfig) *Engine {
+	engine.logCfg = cfg
+	return engine
+}
+
 func (engine *Engine) NewSession() *session.Session {
-	return session.New(engine.db, engine.dialect)
+	s := session.New(engine.db, engine.dialect).UseLogConfig(engine.logCfg)
+	if engine.stmts != nil {
+		s.UseStmtCache(engine.stmts)
+	}
+	return s
 }
 
 // Transaction 事务处理
 func (engine *Engine) Transaction(f TxFunc) (result interface{}, err error) {
+	return engine.TransactionWithOptions(nil, f)
+}
+
+// TransactionWithOptions 以指定的隔离级别/只读选项执行事务，opts 为 nil 时使用默认选项
+func (engine *Engine) TransactionWithOptions(opts *sql.TxOptions, f TxFunc) (result interface{}, err error) {
 	s := engine.NewSession()
-	if err := s.Begin(); err != nil {
+	if err := s.Begin(opts); err != nil {
 		return nil, err
 	}
 	defer func() {
@@ -85,33 +114,45 @@ func difference(a []string, b []string) (diff []string) {
 // Migrate 迁移表结构
 func (engine *Engine) Migrate(value interface{}) error {
 	_, err := engine.Transaction(func(s *session.Session) (result interface{}, err error) {
-		if !s.Model(value).HasTable() { // 表不存在
-			log.Infof("table %s doesn't exist", s.RefTable().Name)
-			return nil, s.CreateTable()
-		}
-		table := s.RefTable() // 结构体
-		rows, _ := s.Raw(fmt.Sprintf("SELECT * FROM %s LIMIT 1", table.Name)).QueryRows()
-		columns, _ := rows.Columns()                     // 字段名列表(数据库字段名)
-		addCols := difference(table.FieldNames, columns) // 新增字段
-		delCols := difference(columns, table.FieldNames) // 删除字段
-		log.Infof("added cols %v, deleted cols %v", addCols, delCols)
-		for _, col := range addCols {
-			f := table.GetField(col)
-			sqlStr := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table.Name, f.Name, f.Type) // 增加字段
-			if _, err = s.Raw(sqlStr).Exec(); err != nil {
-				return
-			}
+		s.Model(value).CallMethod(session.BeforeMigrate, nil)
+		if err = migrate(s); err != nil {
+			return
 		}
-		if len(delCols) == 0 { // 没有删除字段
+		s.CallMethod(session.AfterMigrate, nil)
+		return
+	})
+	return err
+}
+
+// migrate 在事务中对齐表结构：表不存在时建表，否则增删字段并修改类型变化的字段
+func migrate(s *session.Session) (err error) {
+	if !s.HasTable() { // 表不存在
+		log.Infof("table %s doesn't exist", s.RefTable().Name)
+		return s.CreateTable()
+	}
+	table := s.RefTable() // 结构体
+	rows, _ := s.Raw(fmt.Sprintf("SELECT * FROM %s LIMIT 1", table.Name)).QueryRows()
+	columns, _ := rows.Columns()                      // 字段名列表(数据库字段名)
+	addCols := difference(table.ColumnNames, columns) // 新增字段
+	delCols := difference(columns, table.ColumnNames) // 删除字段
+	log.Infof("added cols %v, deleted cols %v", addCols, delCols)
+	for _, col := range addCols {
+		f := table.GetFieldByColumn(col)
+		sqlStr := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table.Name, f.ColumnName, f.Type) // 增加字段
+		if _, err = s.Raw(sqlStr).Exec(); err != nil {
 			return
 		}
+	}
+	if len(delCols) > 0 { // 有删除字段
 		tmp := "tmp_" + table.Name
-		fieldStr := strings.Join(table.FieldNames, ", ")                                       // 字段名列表(结构体字段名)
+		fieldStr := strings.Join(table.ColumnNames, ", ")                                      // 字段名列表(数据库字段名)
 		s.Raw(fmt.Sprintf("CREATE TABLE %s AS SELECT %s FROM %s;", tmp, fieldStr, table.Name)) // 临时表
 		s.Raw(fmt.Sprintf("DROP TABLE %s;", table.Name))                                       // 删除原表
 		s.Raw(fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", tmp, table.Name))                    // 重命名临时表为原表
-		_, err = s.Exec()                                                                      // 执行SQL语句
-		return
-	})
-	return err
+		if _, err = s.Exec(); err != nil {                                                     // 执行SQL语句
+			return
+		}
+	}
+	// 删除字段之后再处理类型变化，避免重建的表(含约束与索引)再被上面的 CREATE TABLE AS 覆盖
+	return s.MigrateColumnTypes()
 }
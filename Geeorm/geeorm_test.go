@@ -1,9 +1,13 @@
 ﻿package geeorm
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/nukecoke1828/7daysProgram/Geeorm/session"
@@ -69,6 +73,56 @@ func transactionCommit(t *testing.T) {
 	}
 }
 
+// 事务内的 Count/Find/First 均通过 s.DB() 走事务连接，能读到尚未提交的写入
+func TestEngine_TransactionReadsOwnWrites(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession().Model(&User{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, err := engine.Transaction(func(s *session.Session) (interface{}, error) {
+		if _, err := s.Model(&User{}).Insert(&User{"Tom", 18}); err != nil {
+			return nil, err
+		}
+		count, err := s.Model(&User{}).Count()
+		if err != nil || count != 1 {
+			t.Fatal("expect count 1 inside transaction, got", count, err)
+		}
+		var users []User
+		if err := s.Find(&users); err != nil || len(users) != 1 {
+			t.Fatal("expect Find to see uncommitted row, got", users, err)
+		}
+		u := &User{}
+		if err := s.Where("Name = ?", "Tom").First(u); err != nil || u.Age != 18 {
+			t.Fatal("expect First to see uncommitted row, got", u, err)
+		}
+		return nil, errors.New("rollback")
+	})
+	if err == nil {
+		t.Fatal("expect transaction to be rolled back")
+	}
+	if count, err := s.Count(); err != nil || count != 0 {
+		t.Fatal("expect no rows after rollback, got", count, err)
+	}
+}
+
+func TestEngine_TransactionWithOptions(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession().Model(&User{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, err := engine.TransactionWithOptions(&sql.TxOptions{ReadOnly: true}, func(s *session.Session) (result interface{}, err error) {
+		return s.Model(&User{}).Insert(&User{"Tom", 18})
+	})
+	if err == nil {
+		t.Fatal("expect write in read-only transaction to fail")
+	}
+	if _, err = s.Insert(&User{"Sam", 25}); err != nil {
+		t.Fatal("failed to write after read-only transaction", err)
+	}
+}
+
 func TestEngine_Migrate(t *testing.T) {
 	engine := OpenDB(t)
 	defer engine.Close()
@@ -85,3 +139,213 @@ func TestEngine_Migrate(t *testing.T) {
 		t.Fatal("Failed to migrate table User, got columns", columns)
 	}
 }
+
+func TestEngine_MigrateColumnType(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
+	// Age 原为 text 类型，结构体中为 int
+	_, _ = s.Raw("CREATE TABLE User(Name text PRIMARY KEY, Age text);").Exec()
+	_, _ = s.Raw("INSERT INTO User(`Name`, `Age`) values (?, ?), (?, ?)", "Tom", "18", "Sam", "25").Exec()
+	if err := engine.Migrate(&User{}); err != nil {
+		t.Fatal("failed to migrate", err)
+	}
+
+	var typ string
+	_ = s.Raw("SELECT type FROM pragma_table_info('User') WHERE name = 'Age'").QueryRow().Scan(&typ)
+	if !strings.EqualFold(typ, "integer") {
+		t.Fatal("expect column Age migrated to integer, got", typ)
+	}
+	var users []User
+	if err := s.Model(&User{}).OrderBy("Name").Find(&users); err != nil ||
+		!reflect.DeepEqual(users, []User{{"Sam", 25}, {"Tom", 18}}) {
+		t.Fatal("expect data preserved after type change, got", users, err)
+	}
+	var storage string
+	_ = s.Raw("SELECT typeof(Age) FROM User WHERE Name = 'Tom'").QueryRow().Scan(&storage)
+	if storage != "integer" {
+		t.Fatal("expect values cast to integer, got", storage)
+	}
+}
+
+type Product struct {
+	Code   string `geeorm:"PRIMARY KEY"`
+	Stock  int    `geeorm:"not null;default:0;index"`
+	Serial int    `geeorm:"unique"`
+}
+
+func TestEngine_MigrateColumnTypeConstraints(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS Product;").Exec()
+	// 主键、带约束与索引的字段类型都发生变化
+	_, _ = s.Raw("CREATE TABLE Product(Code integer PRIMARY KEY, Stock text, Serial text);").Exec()
+	_, _ = s.Raw("INSERT INTO Product(Code, Stock, Serial) values (1, '5', '100'), (2, '7', '200')").Exec()
+	if err := engine.Migrate(&Product{}); err != nil {
+		t.Fatal("failed to migrate", err)
+	}
+	defer func() { _, _ = s.Raw("DROP TABLE IF EXISTS Product;").Exec() }()
+
+	rows, err := s.Raw("SELECT name, type, \"notnull\", COALESCE(dflt_value, ''), pk FROM pragma_table_info('Product')").QueryRows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for rows.Next() {
+		var name, typ, dflt string
+		var notNull, pk int
+		_ = rows.Scan(&name, &typ, &notNull, &dflt, &pk)
+		got = append(got, fmt.Sprintf("%s %s %d %s %d", name, strings.ToLower(typ), notNull, dflt, pk))
+	}
+	_ = rows.Close()
+	if want := []string{"Code text 0  1", "Stock integer 1 0 0", "Serial integer 0  0"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expect constraints kept after type change, got %q", got)
+	}
+	var index string
+	_ = s.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'Product' AND name = 'idx_Product_Stock'").QueryRow().Scan(&index)
+	if index != "idx_Product_Stock" {
+		t.Fatal("expect index recreated after type change")
+	}
+	var products []Product
+	if err := s.Model(&Product{}).OrderBy("Code").Find(&products); err != nil ||
+		!reflect.DeepEqual(products, []Product{{"1", 5, 100}, {"2", 7, 200}}) {
+		t.Fatal("expect data preserved after type change, got", products, err)
+	}
+	if _, err := s.Raw("INSERT INTO Product(Code, Serial) values ('3', 100)").Exec(); err == nil {
+		t.Fatal("expect UNIQUE kept on Serial")
+	}
+	if _, err := s.Raw("INSERT INTO Product(Code, Serial) values ('3', 300)").Exec(); err != nil {
+		t.Fatal("failed to insert with default Stock", err)
+	}
+	var stock int
+	_ = s.Raw("SELECT Stock FROM Product WHERE Code = '3'").QueryRow().Scan(&stock)
+	if stock != 0 {
+		t.Fatal("expect DEFAULT kept on Stock, got", stock)
+	}
+}
+
+type MigrateHooked struct {
+	Name  string `geeorm:"PRIMARY KEY"`
+	calls []string
+}
+
+func (m *MigrateHooked) BeforeMigrate(s *session.Session) error {
+	m.calls = append(m.calls, session.BeforeMigrate)
+	return nil
+}
+
+func (m *MigrateHooked) AfterCreateTable(s *session.Session) error {
+	m.calls = append(m.calls, session.AfterCreateTable)
+	return nil
+}
+
+func (m *MigrateHooked) AfterMigrate(s *session.Session) error {
+	m.calls = append(m.calls, session.AfterMigrate)
+	return nil
+}
+
+func TestEngine_MigrateHooks(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	_, _ = engine.NewSession().Raw("DROP TABLE IF EXISTS MigrateHooked;").Exec()
+
+	m := &MigrateHooked{}
+	if err := engine.Migrate(m); err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{session.BeforeMigrate, session.AfterCreateTable, session.AfterMigrate}
+	if !reflect.DeepEqual(m.calls, expect) {
+		t.Fatal("Failed to call migrate hooks, got", m.calls)
+	}
+}
+
+func TestSession_Savepoint(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession().Model(&User{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	if err := s.Savepoint("sp"); err == nil {
+		t.Fatal("expect error outside transaction")
+	}
+
+	_, err := engine.Transaction(func(s *session.Session) (result interface{}, err error) {
+		s.Model(&User{})
+		if _, err = s.Insert(&User{"Tom", 18}); err != nil {
+			return
+		}
+		if err = s.Savepoint("sp"); err != nil {
+			return
+		}
+		if _, err = s.Insert(&User{"Sam", 25}); err != nil {
+			return
+		}
+		if err = s.RollbackTo("sp"); err != nil { // 撤销 Sam，保留 Tom
+			return
+		}
+		return nil, s.ReleaseSavepoint("sp")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var users []User
+	if err := s.Find(&users); err != nil || !reflect.DeepEqual(users, []User{{"Tom", 18}}) {
+		t.Fatal("failed to rollback to savepoint, got", users, err)
+	}
+}
+
+type captureLogger struct {
+	lines []string
+}
+
+func (l *captureLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestEngine_SetLogConfig(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	logger := &captureLogger{}
+	// 阈值远大于任何语句的耗时，不记录
+	engine.SetLogConfig(&session.LogConfig{SlowThreshold: time.Hour, Logger: logger})
+	var n int
+	if err := engine.NewSession().Raw("SELECT 1").QueryRow().Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.lines) != 0 {
+		t.Fatalf("expect fast query not logged, got %q", logger.lines)
+	}
+
+	// 阈值为 0 时记录全部语句，包括保存点语句
+	engine.SetLogConfig(&session.LogConfig{Logger: logger})
+	_, err := engine.Transaction(func(s *session.Session) (interface{}, error) {
+		if _, err := s.Raw("SELECT 1").Exec(); err != nil {
+			return nil, err
+		}
+		return nil, s.Savepoint("sp1")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.lines) != 2 || !strings.Contains(logger.lines[0], "SELECT 1") || !strings.Contains(logger.lines[1], "SAVEPOINT sp1") ||
+		!strings.Contains(logger.lines[1], "cost=") {
+		t.Fatalf("expect query and savepoint logged, got %q", logger.lines)
+	}
+
+	logger.lines = nil
+	engine.SetLogConfig(&session.LogConfig{Disabled: true, Logger: logger})
+	_, err = engine.Transaction(func(s *session.Session) (interface{}, error) {
+		if _, err := s.Raw("SELECT 1").Exec(); err != nil {
+			return nil, err
+		}
+		if err := s.Savepoint("sp1"); err != nil {
+			return nil, err
+		}
+		return nil, s.ReleaseSavepoint("sp1")
+	})
+	if err != nil || len(logger.lines) != 0 {
+		t.Fatalf("expect no logs when disabled, got %q %v", logger.lines, err)
+	}
+}
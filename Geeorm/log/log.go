@@ -1,6 +1,7 @@
 ﻿package log
 
 import (
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -18,6 +19,8 @@ var ( //使用os.Stdout作为输出，更加灵活性能更好，符合依赖倒
 	infoLog  = log.New(os.Stdout, "\033[34m[INFO]\033[0m ", log.LstdFlags|log.Lshortfile)  // 信息日志记录器（蓝色前缀)
 	loggers  = []*log.Logger{errorLog, infoLog}                                            //日志记录器数组
 	mu       sync.Mutex
+	output   io.Writer = os.Stdout // 当前配置的日志输出
+	logLevel           = InfoLevel // 当前日志级别
 )
 
 var (
@@ -31,13 +34,27 @@ var (
 func SetLevel(level int) {
 	mu.Lock() // 确保线程安全
 	defer mu.Unlock()
+	logLevel = level
+	apply()
+}
+
+// SetOutput 将所有日志重定向到 w(如文件)，已设置的日志级别仍然生效
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	output = w
+	apply()
+}
+
+// 按当前输出和级别重新配置日志记录器，调用方需持有 mu
+func apply() {
 	for _, logger := range loggers { // 重置所有日志输出
-		logger.SetOutput(os.Stdout)
+		logger.SetOutput(output)
 	}
-	if ErrorLevel < level { // 丢弃错误日志，优化性能
+	if ErrorLevel < logLevel { // 丢弃错误日志，优化性能
 		errorLog.SetOutput(ioutil.Discard)
 	}
-	if InfoLevel < level {
+	if InfoLevel < logLevel {
 		infoLog.SetOutput(ioutil.Discard)
 	}
 }
@@ -1,7 +1,9 @@
 ﻿package log
 
 import (
+	"bytes"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -15,3 +17,23 @@ func TestSetLevel(t *testing.T) {
 		t.Fatal("failed to set log level")
 	}
 }
+
+func TestSetOutput(t *testing.T) {
+	var buf bytes.Buffer
+	SetLevel(InfoLevel)
+	SetOutput(&buf)
+	defer SetOutput(os.Stdout)
+	Info("info message")
+	Error("error message")
+	if !strings.Contains(buf.String(), "info message") || !strings.Contains(buf.String(), "error message") {
+		t.Fatal("failed to write logs to custom output, got", buf.String())
+	}
+	buf.Reset()
+	SetLevel(ErrorLevel)
+	defer SetLevel(InfoLevel)
+	Info("info message")
+	Error("error message")
+	if strings.Contains(buf.String(), "info message") || !strings.Contains(buf.String(), "error message") {
+		t.Fatal("failed to keep custom output after set level, got", buf.String())
+	}
+}
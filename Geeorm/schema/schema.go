@@ -3,22 +3,75 @@
 import (
 	"go/ast"
 	"reflect"
+	"strings"
 
 	"github.com/nukecoke1828/7daysProgram/Geeorm/dialect"
 )
 
 type Field struct {
-	Name string // 数据库字段名
-	Type string // 数据库字段类型
-	Tag  string // 字段标签
+	Name        string // 结构体字段名
+	ColumnName  string // 数据库字段名(默认与结构体字段名相同)
+	Type        string // 数据库字段类型
+	Tag         string // 字段标签(未被解析的原始约束，如 PRIMARY KEY)
+	NotNull     bool   // NOT NULL 约束
+	Unique      bool   // UNIQUE 约束
+	Default     string // 默认值(原样写入DDL，为空表示无默认值)
+	PrimaryKey  bool   // 是否为主键(PRIMARY KEY 仍保留在 Tag 中写入DDL)
+	Index       bool   // 是否在该列上建立普通索引
+	UniqueIndex bool   // 是否在该列上建立唯一索引
+}
+
+// Relation has-one 关联：关联表通过外键字段指向本表主键，关联字段不对应数据库列
+type Relation struct {
+	FieldName  string       // 本结构体中的关联字段名
+	ForeignKey string       // 关联表中指向本表主键的字段(字段名或列名)
+	Type       reflect.Type // 关联的结构体类型(已去掉指针)
+	Ptr        bool         // 关联字段是否为指针
 }
 
 type Schema struct {
-	Model      interface{}       // 原始结构体实例
-	Name       string            // 表名
-	Fields     []*Field          // 字段列表
-	FieldNames []string          // 字段名列表
-	fieldMap   map[string]*Field // 字段名-字段映射
+	Model       interface{}       // 原始结构体实例
+	Name        string            // 表名
+	Fields      []*Field          // 字段列表
+	FieldNames  []string          // 字段名列表(结构体字段名)
+	ColumnNames []string          // 列名列表(数据库字段名)
+	IndexFields []*Field          // 需要建立索引的字段(index/unique_index)
+	Relations   []*Relation       // has-one 关联字段(带 foreignkey 标签的结构体字段)
+	fieldMap    map[string]*Field // 字段名-字段映射
+}
+
+// 根据关联字段名获取关联信息
+func (schema *Schema) GetRelation(name string) *Relation {
+	for _, rel := range schema.Relations {
+		if rel.FieldName == name {
+			return rel
+		}
+	}
+	return nil
+}
+
+// 解析关联字段：类型为结构体或结构体指针且 tag 中带 foreignkey:字段 时视为 has-one 关联
+func parseRelation(p reflect.StructField) *Relation {
+	typ, ptr := p.Type, false
+	if typ.Kind() == reflect.Ptr {
+		typ, ptr = typ.Elem(), true
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	tag, _ := p.Tag.Lookup("geeorm")
+	for _, opt := range strings.Split(tag, ";") {
+		opt = strings.TrimSpace(opt)
+		if strings.HasPrefix(strings.ToLower(opt), "foreignkey:") {
+			return &Relation{
+				FieldName:  p.Name,
+				ForeignKey: strings.TrimSpace(opt[len("foreignkey:"):]),
+				Type:       typ,
+				Ptr:        ptr,
+			}
+		}
+	}
+	return nil
 }
 
 // 获取字段信息
@@ -26,6 +79,57 @@ func (Schema *Schema) GetField(name string) *Field {
 	return Schema.fieldMap[name]
 }
 
+// 根据数据库字段名获取字段信息
+func (schema *Schema) GetFieldByColumn(column string) *Field {
+	for _, field := range schema.Fields {
+		if field.ColumnName == column {
+			return field
+		}
+	}
+	return nil
+}
+
+// 获取主键字段，没有主键时返回nil
+func (schema *Schema) PrimaryField() *Field {
+	for _, field := range schema.Fields {
+		if field.PrimaryKey {
+			return field
+		}
+	}
+	return nil
+}
+
+// 解析tag, 选项之间以分号分隔, 如 geeorm:"PRIMARY KEY;column:user_name;not null;default:0;unique;index"
+// column/not null/default/unique/index/unique_index 解析为字段的结构化属性，其余选项原样保留在 Tag 中
+func parseTag(field *Field, tag string) {
+	var opts []string
+	for _, opt := range strings.Split(tag, ";") {
+		opt = strings.TrimSpace(opt)
+		lower := strings.ToLower(opt)
+		switch {
+		case opt == "":
+		case strings.HasPrefix(lower, "column:"): // 列名映射
+			field.ColumnName = strings.TrimSpace(opt[len("column:"):])
+		case strings.HasPrefix(lower, "default:"): // 默认值
+			field.Default = strings.TrimSpace(opt[len("default:"):])
+		case lower == "not null": // 非空约束
+			field.NotNull = true
+		case lower == "unique": // 唯一约束
+			field.Unique = true
+		case lower == "index": // 普通索引
+			field.Index = true
+		case lower == "unique_index": // 唯一索引
+			field.UniqueIndex = true
+		case lower == "primary key": // 主键
+			field.PrimaryKey = true
+			opts = append(opts, opt)
+		default:
+			opts = append(opts, opt)
+		}
+	}
+	field.Tag = strings.Join(opts, " ")
+}
+
 // 解析结构体，获取字段信息
 // dest：结构体实例
 func Parse(dest interface{}, d dialect.Dialect) *Schema {
@@ -35,27 +139,53 @@ func Parse(dest interface{}, d dialect.Dialect) *Schema {
 		Name:     modelType.Name(), // 使用结构体名作为表名
 		fieldMap: make(map[string]*Field),
 	}
-	for i := 0; i < modelType.NumField(); i++ { // 遍历结构体字段
-		p := modelType.Field(i)
-		// !p.Anonymous：排除嵌入式字段（非匿名字段）
+	schema.parseFields(modelType, d)
+	return schema
+}
+
+// 解析结构体类型的字段，嵌入的结构体（非指针）会被展开，交给 FieldByName 按提升字段取值
+// 字段提升遵循 Go 的规则（reflect.VisibleFields）：外层字段遮蔽内层同名字段，
+// 同一深度的同名字段有歧义，全部忽略
+func (schema *Schema) parseFields(typ reflect.Type, d dialect.Dialect) {
+	for _, p := range reflect.VisibleFields(typ) { // 遍历结构体字段（含提升字段）
 		// ast.IsExported(p.Name)：只处理导出字段（首字母大写）
-		if !p.Anonymous && ast.IsExported(p.Name) {
-			field := &Field{
-				Name: p.Name,
-				// reflect.New(p.Type) 得到 *T，
-				// 再用 Indirect 拿到 T，
-				// 最后交给方言 DataTypeOF 得到 "text" / "integer" / "datetime" 等
-				Type: d.DataTypeOF(reflect.Indirect(reflect.New(p.Type))),
-			}
-			if v, ok := p.Tag.Lookup("geeorm"); ok { // 解析tag
-				field.Tag = v
-			}
-			schema.Fields = append(schema.Fields, field)
-			schema.FieldNames = append(schema.FieldNames, p.Name)
-			schema.fieldMap[p.Name] = field
+		// 嵌入字段本身不映射为列，经由嵌入指针提升的字段也不展开
+		if p.Anonymous || !ast.IsExported(p.Name) || !embeddedByValue(typ, p.Index) {
+			continue
+		}
+		if rel := parseRelation(p); rel != nil { // 关联字段不映射为列
+			schema.Relations = append(schema.Relations, rel)
+			continue
+		}
+		field := &Field{
+			Name:       p.Name,
+			ColumnName: p.Name,
+			// reflect.New(p.Type) 得到 *T，
+			// 再用 Indirect 拿到 T，
+			// 最后交给方言 DataTypeOF 得到 "text" / "integer" / "datetime" 等
+			Type: d.DataTypeOF(reflect.Indirect(reflect.New(p.Type))),
+		}
+		if v, ok := p.Tag.Lookup("geeorm"); ok { // 解析tag
+			parseTag(field, v)
+		}
+		schema.Fields = append(schema.Fields, field)
+		schema.FieldNames = append(schema.FieldNames, p.Name)
+		schema.ColumnNames = append(schema.ColumnNames, field.ColumnName)
+		schema.fieldMap[p.Name] = field
+		if field.Index || field.UniqueIndex {
+			schema.IndexFields = append(schema.IndexFields, field)
 		}
 	}
-	return schema
+}
+
+// 判断 index 路径上的嵌入字段是否都是结构体值（而非指针）
+func embeddedByValue(typ reflect.Type, index []int) bool {
+	for i := 1; i < len(index); i++ {
+		if typ.FieldByIndex(index[:i]).Type.Kind() != reflect.Struct {
+			return false
+		}
+	}
+	return true
 }
 
 // 把对象实例转成“列值切片”(把「实例对象」翻译成「按列顺序排好的值切片」，供 SQL 占位符使用)
@@ -1,6 +1,7 @@
 ﻿package schema
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/nukecoke1828/7daysProgram/Geeorm/dialect"
@@ -21,4 +22,143 @@ func TestParse(t *testing.T) {
 	if schema.GetField("Name").Tag != "PRIMARY KEY" {
 		t.Fatal("failed tp parse primary key tag")
 	}
+	if schema.PrimaryField() != schema.GetField("Name") {
+		t.Fatal("failed to find primary field")
+	}
+}
+
+type Member struct {
+	UserName string `geeorm:"PRIMARY KEY;column:user_name"`
+}
+
+func TestParse_ColumnName(t *testing.T) {
+	schema := Parse(&Member{}, TestDial)
+	field := schema.GetField("UserName")
+	if field == nil || field.ColumnName != "user_name" || field.Tag != "PRIMARY KEY" {
+		t.Fatal("failed to parse column tag")
+	}
+	if schema.ColumnNames[0] != "user_name" || schema.FieldNames[0] != "UserName" {
+		t.Fatal("failed to parse column names")
+	}
+}
+
+type Product struct {
+	Code  string `geeorm:"not null;unique"`
+	Price int    `geeorm:"default:0"`
+}
+
+func TestParse_Constraints(t *testing.T) {
+	schema := Parse(&Product{}, TestDial)
+	code, price := schema.GetField("Code"), schema.GetField("Price")
+	if !code.NotNull || !code.Unique || code.Default != "" || code.Tag != "" {
+		t.Fatal("failed to parse not null/unique tag")
+	}
+	if price.NotNull || price.Unique || price.Default != "0" {
+		t.Fatal("failed to parse default tag")
+	}
+}
+
+type Tagged struct {
+	Email string `geeorm:"unique_index"`
+	Age   int    `geeorm:"index"`
+	Name  string
+}
+
+func TestParse_Index(t *testing.T) {
+	schema := Parse(&Tagged{}, TestDial)
+	email, age := schema.GetField("Email"), schema.GetField("Age")
+	if !email.UniqueIndex || email.Index || !age.Index || age.UniqueIndex || email.Tag != "" {
+		t.Fatal("failed to parse index tag")
+	}
+	if len(schema.IndexFields) != 2 || schema.IndexFields[0] != email || schema.IndexFields[1] != age {
+		t.Fatal("failed to track index fields, got", schema.IndexFields)
+	}
+}
+
+type Base struct {
+	ID   int `geeorm:"PRIMARY KEY"`
+	Name string
+}
+
+type Post struct {
+	Base
+	Name  string `geeorm:"not null"`
+	Title string
+}
+
+func TestParse_Embedded(t *testing.T) {
+	schema := Parse(&Post{}, TestDial)
+	if !reflect.DeepEqual(schema.FieldNames, []string{"ID", "Name", "Title"}) {
+		t.Fatal("failed to flatten embedded struct, got", schema.FieldNames)
+	}
+	if !schema.GetField("Name").NotNull || schema.PrimaryField().Name != "ID" {
+		t.Fatal("failed to prefer outer field")
+	}
+	post := &Post{Base: Base{ID: 1, Name: "base"}, Name: "post", Title: "hello"}
+	if values := schema.RecordValues(post); !reflect.DeepEqual(values, []interface{}{1, "post", "hello"}) {
+		t.Fatal("failed to get embedded record values, got", values)
+	}
+}
+
+type Audit struct {
+	ID      int
+	Creator string
+}
+
+type Meta struct {
+	ID     int
+	Source string
+}
+
+// Audited 嵌入的 Audit 与 Meta 都有 ID，同一深度有歧义，两者都不映射
+type Audited struct {
+	Audit
+	Meta
+	Name string
+}
+
+// Wrapped 外层的 ID 遮蔽嵌入结构体中有歧义的 ID
+type Wrapped struct {
+	Audited
+	ID int `geeorm:"PRIMARY KEY"`
+}
+
+func TestParse_EmbeddedAmbiguous(t *testing.T) {
+	schema := Parse(&Audited{}, TestDial)
+	if !reflect.DeepEqual(schema.FieldNames, []string{"Creator", "Source", "Name"}) {
+		t.Fatal("expect ambiguous ID dropped, got", schema.FieldNames)
+	}
+	audited := &Audited{Audit{1, "tom"}, Meta{2, "api"}, "post"}
+	if values := schema.RecordValues(audited); !reflect.DeepEqual(values, []interface{}{"tom", "api", "post"}) {
+		t.Fatal("failed to get record values, got", values)
+	}
+
+	schema = Parse(&Wrapped{}, TestDial)
+	if !reflect.DeepEqual(schema.FieldNames, []string{"Creator", "Source", "Name", "ID"}) || schema.PrimaryField().Name != "ID" {
+		t.Fatal("expect outer ID to win, got", schema.FieldNames)
+	}
+	wrapped := &Wrapped{Audited: *audited, ID: 3}
+	if values := schema.RecordValues(wrapped); !reflect.DeepEqual(values, []interface{}{"tom", "api", "post", 3}) {
+		t.Fatal("failed to get record values, got", values)
+	}
+}
+
+type Owner struct {
+	ID  int  `geeorm:"PRIMARY KEY"`
+	Pet *Pet `geeorm:"foreignkey:OwnerID"`
+}
+
+type Pet struct {
+	OwnerID int
+}
+
+func TestParse_Relation(t *testing.T) {
+	schema := Parse(&Owner{}, TestDial)
+	rel := schema.GetRelation("Pet")
+	if rel == nil || rel.ForeignKey != "OwnerID" || !rel.Ptr || rel.Type.Name() != "Pet" {
+		t.Fatal("failed to parse relation, got", rel)
+	}
+	if len(schema.Fields) != 1 || schema.GetField("Pet") != nil {
+		t.Fatal("relation field should not be mapped to a column")
+	}
 }
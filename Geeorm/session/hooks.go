@@ -15,6 +15,11 @@ const (
 	AfterDelete  = "AfterDelete"
 	BeforeInsert = "BeforeInsert"
 	AfterInsert  = "AfterInsert"
+
+	BeforeCreateTable = "BeforeCreateTable"
+	AfterCreateTable  = "AfterCreateTable"
+	BeforeMigrate     = "BeforeMigrate"
+	AfterMigrate      = "AfterMigrate"
 )
 
 // 反射钩子触发器(钩子方法定义在「表模型结构体」（或传入的任意对象）)
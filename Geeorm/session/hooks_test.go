@@ -36,3 +36,26 @@ func TestSession_CallMethod(t *testing.T) {
 		t.Fatal("Failed to call hooks after query, got", u)
 	}
 }
+
+type Indexed struct {
+	Name string `geeorm:"PRIMARY KEY"`
+	Age  int
+}
+
+func (i *Indexed) AfterCreateTable(s *Session) error {
+	_, err := s.Raw("CREATE INDEX idx_indexed_age ON Indexed(Age);").Exec()
+	return err
+}
+
+func TestSession_CreateTableHooks(t *testing.T) {
+	s := NewSession().Model(&Indexed{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	var name string
+	row := s.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND name = ?", "idx_indexed_age").QueryRow()
+	if err := row.Scan(&name); err != nil || name != "idx_indexed_age" {
+		t.Fatal("AfterCreateTable hook should create the index, got", name, err)
+	}
+}
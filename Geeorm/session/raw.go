@@ -1,8 +1,12 @@
 ﻿package session
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/nukecoke1828/7daysProgram/Geeorm/clause"
 	"github.com/nukecoke1828/7daysProgram/Geeorm/dialect"
@@ -14,13 +18,49 @@ var _ CommonDB = (*sql.DB)(nil) // 确保 CommonDB 接口实现
 var _ CommonDB = (*sql.Tx)(nil) // 确保 CommonDB 接口实现
 
 type Session struct { // 与数据库交互的会话
-	db       *sql.DB         // 数据库连接池
-	sql      strings.Builder // sql缓冲区
-	sqlVars  []interface{}   // sql参数列表
-	dialect  dialect.Dialect // 数据库方言
-	refTable *schema.Schema  // 引用的表结构
-	clause   clause.Clause   // SQL子句组合
-	tx       *sql.Tx         // 事务
+	db         *sql.DB         // 数据库连接池
+	sql        strings.Builder // sql缓冲区
+	sqlVars    []interface{}   // sql参数列表
+	dialect    dialect.Dialect // 数据库方言
+	refTable   *schema.Schema  // 引用的表结构
+	clause     clause.Clause   // SQL子句组合
+	tx         *sql.Tx         // 事务
+	readOnly   bool            // 事务连接是否被切换为只读模式
+	stmts      *StmtCache      // 预编译语句缓存(可选)
+	where      [][]condition   // WHERE 条件分组，组间 AND，组内 OR
+	distinct   bool            // 查询是否去重
+	columns    []string        // 查询的列，为空时查询全部列
+	preloads   []string        // Find 后需要预加载的关联字段
+	orders     []string        // ORDER BY 的排序项，多次 OrderBy 依次追加
+	limit      int             // LIMIT 行数，-1 表示不限制
+	offset     int             // OFFSET 偏移量
+	logCfg     *LogConfig      // SQL 日志配置，为 nil 时记录全部语句
+	err        error           // 构建语句时记录的错误(如不合法的排序项)，由下一次执行返回
+	dryRun     bool            // 下一条写语句只记录不执行，由 DryRun 设置
+	dryRunSQL  string          // 最近一次 DryRun 记录的 SQL
+	dryRunVars []interface{}   // 最近一次 DryRun 记录的参数
+	// allowGlobalDelete 允许下一条 Delete 在没有 WHERE 条件时执行
+	allowGlobalDelete bool
+	// allowGlobalUpdate 允许下一条 Update 在没有 WHERE 条件时执行
+	allowGlobalUpdate bool
+}
+
+// Logger SQL 日志输出接口，*log.Logger 即满足
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// LogConfig 控制会话如何记录执行的 SQL
+type LogConfig struct {
+	Disabled      bool          // 关闭 SQL 日志
+	SlowThreshold time.Duration // 只记录耗时不小于该值的语句，0 表示全部记录
+	Logger        Logger        // 日志输出，为 nil 时使用 log.Infof
+}
+
+// condition 单个 WHERE 条件及其参数
+type condition struct {
+	desc string
+	args []interface{}
 }
 
 // CommonDB 通用数据库接口，包含sql.DB和sql.Tx的接口方法
@@ -35,6 +75,7 @@ func New(db *sql.DB, dialect dialect.Dialect) *Session {
 	return &Session{
 		db:      db,
 		dialect: dialect,
+		limit:   -1,
 	}
 }
 
@@ -42,13 +83,88 @@ func (s *Session) Clear() {
 	s.sql.Reset()              // 清空sql缓冲区
 	s.sqlVars = nil            // 清空sql参数列表
 	s.clause = clause.Clause{} // 清空SQL子句组合
+	s.where = nil              // 清空WHERE条件
+	s.distinct = false
+	s.columns = nil
+	s.preloads = nil
+	s.orders = nil
+	s.limit, s.offset = -1, 0
+	s.allowGlobalDelete = false
+	s.allowGlobalUpdate = false
+	s.err = nil
+	s.dryRun = false
+}
+
+// DryRun 让下一条 Insert/Update/Delete 等写语句只生成 SQL 而不执行，执行后失效；
+// 语句按 0 行受影响返回，钩子照常触发，生成的 SQL 与参数可通过 DryRunSQL 取得
+func (s *Session) DryRun() *Session {
+	s.dryRun = true
+	return s
 }
 
-// DB 如果有事务，则返回事务对象，否则返回数据库连接池对象
+// DryRunSQL 返回最近一次 DryRun 记录的 SQL 与参数
+func (s *Session) DryRunSQL() (string, []interface{}) {
+	return s.dryRunSQL, s.dryRunVars
+}
+
+// dryRunResult DryRun 时代替驱动返回的结果，受影响行数与自增ID均为 0
+type dryRunResult struct{}
+
+func (dryRunResult) LastInsertId() (int64, error) { return 0, nil }
+func (dryRunResult) RowsAffected() (int64, error) { return 0, nil }
+
+// takeErr 返回构建语句时记录的错误，存在时清空会话，调用方不再执行SQL
+func (s *Session) takeErr() error {
+	err := s.err
+	if err != nil {
+		s.Clear()
+	}
+	return err
+}
+
+// UseStmtCache 让会话在事务外通过预编译语句缓存执行SQL
+func (s *Session) UseStmtCache(stmts *StmtCache) *Session {
+	s.stmts = stmts
+	return s
+}
+
+// UseLogConfig 设置会话的 SQL 日志配置
+func (s *Session) UseLogConfig(cfg *LogConfig) *Session {
+	s.logCfg = cfg
+	return s
+}
+
+// logSQL 记录当前 SQL 及自 start 起的耗时，需在 Clear 之前调用
+func (s *Session) logSQL(start time.Time) {
+	s.logStatement(s.sql.String(), s.sqlVars, start)
+}
+
+// logStatement 按会话的日志配置记录语句及自 start 起的耗时
+func (s *Session) logStatement(sql string, vars []interface{}, start time.Time) {
+	cost := time.Since(start)
+	cfg := s.logCfg
+	if cfg == nil {
+		log.Infof("%s %v cost=%s", sql, vars, cost)
+		return
+	}
+	if cfg.Disabled || cost < cfg.SlowThreshold {
+		return
+	}
+	if cfg.Logger == nil {
+		log.Infof("%s %v cost=%s", sql, vars, cost)
+		return
+	}
+	cfg.Logger.Printf("%s %v cost=%s", sql, vars, cost)
+}
+
+// DB 如果有事务，则返回事务对象；开启了语句缓存则返回缓存；否则返回数据库连接池对象
 func (s *Session) DB() CommonDB {
 	if s.tx != nil {
 		return s.tx
 	}
+	if s.stmts != nil {
+		return s.stmts
+	}
 	return s.db
 }
 
@@ -60,9 +176,68 @@ func (s *Session) Raw(sql string, values ...interface{}) *Session {
 	return s
 }
 
+// RawNamed 构建使用命名参数的sql语句，将 :name 占位符改写为 ? 并按出现顺序追加参数，同名参数可重复使用
+// 单引号字符串内的内容与 :: 类型转换不会被当作占位符；params 中缺少参数时不写入任何语句，
+// 并使下一次执行返回该错误
+func (s *Session) RawNamed(sql string, params map[string]interface{}) *Session {
+	query, values, err := bindNamed(sql, params)
+	if err != nil {
+		if s.err == nil {
+			s.err = err
+		}
+		return s
+	}
+	return s.Raw(query, values...)
+}
+
+// bindNamed 将命名参数改写为位置参数，遇到 params 中没有的参数时返回错误
+func bindNamed(sql string, params map[string]interface{}) (string, []interface{}, error) {
+	var b strings.Builder
+	var values []interface{}
+	inQuote := false
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+		case c == ':' && !inQuote && i+1 < len(sql) && sql[i+1] == ':': // :: 类型转换
+			b.WriteString("::")
+			i++
+			continue
+		case c == ':' && !inQuote && i+1 < len(sql) && isNameChar(sql[i+1]):
+			j := i + 1
+			for j < len(sql) && isNameChar(sql[j]) {
+				j++
+			}
+			name := sql[i+1 : j]
+			value, ok := params[name]
+			if !ok {
+				return "", nil, fmt.Errorf("geeorm: missing named parameter :%s", name)
+			}
+			values = append(values, value)
+			b.WriteByte('?')
+			i = j - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), values, nil
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
 func (s *Session) Exec() (result sql.Result, err error) {
+	if err = s.takeErr(); err != nil {
+		return nil, err
+	}
 	defer s.Clear()
-	log.Info(s.sql.String(), s.sqlVars)
+	if s.dryRun { // 只记录语句，不访问数据库
+		s.dryRunSQL, s.dryRunVars = strings.TrimSpace(s.sql.String()), s.sqlVars
+		return dryRunResult{}, nil
+	}
+	defer s.logSQL(time.Now()) // 先于 Clear 执行，统计驱动调用耗时
 	// s.DB()	取底层 *sql.DB 连接池
 	// s.sql.String()	把 strings.Builder 里的字节数组转成一个最终 SQL 字符串
 	// s.sqlVars...	把切片里的参数 逐一展开
@@ -73,18 +248,148 @@ func (s *Session) Exec() (result sql.Result, err error) {
 }
 
 // QueryRow 查询单条数据
+// 构建语句时记录了错误(如不合法的排序项)时不执行语句，返回的 *sql.Row 在 Scan 时报错；
+// 需要取得原始错误时使用 ScanRow
 func (s *Session) QueryRow() *sql.Row {
+	if err := s.takeErr(); err != nil {
+		log.Error(err)
+		return s.failedRow()
+	}
 	defer s.Clear()
-	log.Info(s.sql.String(), s.sqlVars)
+	defer s.logSQL(time.Now())
 	return s.DB().QueryRow(s.sql.String(), s.sqlVars...)
 }
 
+// ScanRow 查询单条数据并扫描到 dest，构建语句时记录的错误会原样返回
+func (s *Session) ScanRow(dest ...interface{}) error {
+	if err := s.takeErr(); err != nil {
+		return err
+	}
+	return s.QueryRow().Scan(dest...)
+}
+
+// failedRow 返回一个 Scan 必定失败的 *sql.Row：使用已取消的 context 查询，语句不会发送到数据库
+func (s *Session) failedRow() *sql.Row {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return s.db.QueryRowContext(ctx, "SELECT 1")
+}
+
 // QueryRows 查询多条数据
 func (s *Session) QueryRows() (rows *sql.Rows, err error) {
+	if err = s.takeErr(); err != nil {
+		return nil, err
+	}
 	defer s.Clear()
-	log.Info(s.sql.String(), s.sqlVars)
+	defer s.logSQL(time.Now())
 	if rows, err = s.DB().Query(s.sql.String(), s.sqlVars...); err != nil {
 		log.Error(err)
 	}
 	return rows, err
 }
+
+// ToSQL 按 Find 的规则根据当前的条件、排序与 Limit/Offset 生成 SELECT 语句与参数，
+// 但不执行，也不清空会话状态，便于调试；写语句请使用 DryRun
+func (s *Session) ToSQL() (string, []interface{}) {
+	table := s.RefTable()
+	fields, err := s.selectedFields(table)
+	if err != nil {
+		log.Error(err)
+		return "", nil
+	}
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		columns = append(columns, field.ColumnName)
+	}
+	var selectClause clause.Clause // 单独生成 SELECT 子句，不写入会话
+	selectClause.Set(clause.SELECT, table.Name, columns, s.distinct)
+	sql, _ := selectClause.Build(clause.SELECT)
+	rest, vars := s.clause.Build(clause.WHERE, clause.ORDERBY, clause.LIMIT)
+	if rest != "" {
+		sql += " " + rest
+	}
+	return sql, vars
+}
+
+// QueryMaps 执行原生 SQL，将每一行按列名扫描为 map，适合联表、统计等无需定义结构体的临时查询
+// 文本列以 string 返回，而非 []byte
+func (s *Session) QueryMaps(sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := s.Raw(sql, args...).QueryRows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var results []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns)) // 每列的扫描目标指针
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		record := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			record[column] = values[i]
+		}
+		results = append(results, record)
+	}
+	return results, rows.Err()
+}
+
+// QueryStruct 执行原生 SQL，将第一行扫描进 dest(结构体指针)，适合联表等无法用 Find 表达的查询
+// 结果列按名称(不区分大小写)匹配结构体的导出字段，没有对应字段的列会被忽略；没有结果时返回 ErrRecordNotFound
+func (s *Session) QueryStruct(dest interface{}, sql string, args ...interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		s.Clear()
+		return fmt.Errorf("geeorm: QueryStruct expects a pointer to struct, got %T", dest)
+	}
+	rows, err := s.Raw(sql, args...).QueryRows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return ErrRecordNotFound
+	}
+	elem := v.Elem()
+	table := schema.Parse(dest, s.dialect)       // 与 Find 相同的列映射：支持 column 标签与嵌入结构体
+	targets := make([]interface{}, len(columns)) // 每列的扫描目标指针
+	for i, column := range columns {
+		if field := fieldByColumn(table, column); field != nil {
+			targets[i] = elem.FieldByName(field.Name).Addr().Interface()
+		} else {
+			targets[i] = new(interface{}) // 丢弃没有对应字段的列
+		}
+	}
+	return rows.Scan(targets...)
+}
+
+// fieldByColumn 按列名查找字段，精确匹配失败时再不区分大小写匹配（便于 SQL 别名）
+func fieldByColumn(table *schema.Schema, column string) *schema.Field {
+	if field := table.GetFieldByColumn(column); field != nil {
+		return field
+	}
+	for _, field := range table.Fields {
+		if strings.EqualFold(field.ColumnName, column) {
+			return field
+		}
+	}
+	return nil
+}
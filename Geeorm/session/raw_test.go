@@ -2,7 +2,10 @@
 
 import (
 	"database/sql"
+	"errors"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -46,3 +49,117 @@ func TestSession_QueryRows(t *testing.T) {
 		t.Fatal("failed to query db", err)
 	}
 }
+
+func TestSession_QueryMaps(t *testing.T) {
+	s := NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
+	_, _ = s.Raw("CREATE TABLE User(Name text);").Exec()
+	_, _ = s.Raw("INSERT INTO User(`Name`) values (?), (?), (?)", "Tom", "Tom", "Jerry").Exec()
+	records, err := s.QueryMaps("SELECT Name AS name, COUNT(*) AS total FROM User GROUP BY Name ORDER BY Name")
+	expect := []map[string]interface{}{
+		{"name": "Jerry", "total": int64(1)},
+		{"name": "Tom", "total": int64(2)},
+	}
+	if err != nil || !reflect.DeepEqual(records, expect) {
+		t.Fatal("failed to query maps, got", records, err)
+	}
+}
+
+func TestSession_QueryStruct(t *testing.T) {
+	s := NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
+	_, _ = s.Raw("CREATE TABLE User(Name text, DeptID integer);").Exec()
+	_, _ = s.Raw("DROP TABLE IF EXISTS Dept;").Exec()
+	_, _ = s.Raw("CREATE TABLE Dept(ID integer, Title text);").Exec()
+	_, _ = s.Raw("INSERT INTO User(`Name`, `DeptID`) values (?, ?), (?, ?)", "Tom", 1, "Jerry", 2).Exec()
+	_, _ = s.Raw("INSERT INTO Dept(`ID`, `Title`) values (?, ?), (?, ?)", 1, "dev", 2, "ops").Exec()
+
+	type userDept struct {
+		Name  string
+		Title string
+	}
+	var result userDept
+	err := s.QueryStruct(&result, "SELECT u.Name AS name, d.Title AS title, d.ID AS unused FROM User u JOIN Dept d ON u.DeptID = d.ID WHERE u.Name = ?", "Jerry")
+	if err != nil || result != (userDept{"Jerry", "ops"}) {
+		t.Fatal("failed to query struct, got", result, err)
+	}
+	err = s.QueryStruct(&result, "SELECT Name FROM User WHERE Name = ?", "Nobody")
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Fatal("expect ErrRecordNotFound, got", err)
+	}
+	if err := s.QueryStruct(result, "SELECT Name FROM User"); err == nil {
+		t.Fatal("expect error for non-pointer dest")
+	}
+}
+
+func TestSession_QueryStructSchema(t *testing.T) {
+	s := NewSession().Model(&Article{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Insert(&Article{Model{ID: 7}, "Hello"})
+	var article Article // 嵌入结构体中的字段
+	if err := s.QueryStruct(&article, "SELECT ID, Title FROM Article"); err != nil || article.ID != 7 || article.Title != "Hello" {
+		t.Fatal("failed to query struct with embedded fields, got", article, err)
+	}
+
+	s = NewSession().Model(&Member{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Insert(&Member{1, "Tom"})
+	var member, found Member // column 标签
+	if err := s.QueryStruct(&member, "SELECT id, user_name FROM Member"); err != nil || member != (Member{1, "Tom"}) {
+		t.Fatal("failed to query struct with column tags, got", member, err)
+	}
+	if err := s.First(&found); err != nil || found != member {
+		t.Fatal("QueryStruct should agree with First, got", found, err)
+	}
+}
+
+func TestSession_RawNamed(t *testing.T) {
+	s := NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
+	_, _ = s.Raw("CREATE TABLE User(Name text, Nick text);").Exec()
+	_, _ = s.Raw("INSERT INTO User(`Name`, `Nick`) values (?, ?), (?, ?)", "Tom", "Tom", "Jerry", "Tom").Exec()
+
+	s.RawNamed("SELECT count(*) FROM User WHERE Name = :name AND Nick = :name AND Name != ':skip'", map[string]interface{}{"name": "Tom"})
+	sql, vars := strings.TrimSpace(s.sql.String()), s.sqlVars
+	if sql != "SELECT count(*) FROM User WHERE Name = ? AND Nick = ? AND Name != ':skip'" || !reflect.DeepEqual(vars, []interface{}{"Tom", "Tom"}) {
+		t.Fatal("failed to rewrite named params, got", sql, vars)
+	}
+	var count int
+	if err := s.QueryRow().Scan(&count); err != nil || count != 1 {
+		t.Fatal("expect 1, but got", count, err)
+	}
+
+	// 可直接链式执行
+	if _, err := s.RawNamed("UPDATE User SET Nick = :nick WHERE Name = :name", map[string]interface{}{"name": "Jerry", "nick": "Mouse"}).Exec(); err != nil {
+		t.Fatal("failed to exec named statement", err)
+	}
+
+	// 参数名拼写错误时不写入语句，下一次执行返回错误，不会以 NULL 绑定后静默执行
+	s.RawNamed("UPDATE User SET Nick = :nick WHERE Name = :nmae", map[string]interface{}{"name": "Tom", "nick": "Cat"})
+	if s.sql.Len() != 0 || len(s.sqlVars) != 0 {
+		t.Fatal("failed binding should not write the statement, got", s.sql.String(), s.sqlVars)
+	}
+	if _, err := s.Exec(); err == nil || !strings.Contains(err.Error(), ":nmae") {
+		t.Fatal("expect missing parameter error, got", err)
+	}
+	rows, err := s.RawNamed("SELECT Name FROM User WHERE Nick = :nick", nil).QueryRows()
+	if err == nil || !strings.Contains(err.Error(), ":nick") {
+		t.Fatal("expect missing parameter error from QueryRows, got", rows, err)
+	}
+	var nicks []string
+	rows, err = s.Raw("SELECT Nick FROM User ORDER BY Name").QueryRows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var nick string
+		_ = rows.Scan(&nick)
+		nicks = append(nicks, nick)
+	}
+	_ = rows.Close()
+	if !reflect.DeepEqual(nicks, []string{"Mouse", "Tom"}) {
+		t.Fatal("failed named statement should not be executed, got", nicks)
+	}
+}
@@ -2,9 +2,25 @@
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/nukecoke1828/7daysProgram/Geeorm/clause"
+	"github.com/nukecoke1828/7daysProgram/Geeorm/dialect"
+	"github.com/nukecoke1828/7daysProgram/Geeorm/log"
+	"github.com/nukecoke1828/7daysProgram/Geeorm/schema"
+)
+
+var (
+	// ErrRecordNotFound First、QueryStruct 等查询单条记录时没有结果
+	ErrRecordNotFound = errors.New("NOT FOUND")
+	// ErrGlobalDelete 没有 WHERE 条件的 Delete 会删除整张表，默认被拒绝
+	ErrGlobalDelete = errors.New("geeorm: unconditional delete blocked, call AllowGlobalDelete to delete all rows")
+	// ErrGlobalUpdate 没有 WHERE 条件的 Update 会更新整张表，默认被拒绝
+	ErrGlobalUpdate = errors.New("geeorm: unconditional update blocked, call AllowGlobalUpdate to update all rows")
 )
 
 func (s *Session) Insert(values ...interface{}) (int64, error) {
@@ -13,7 +29,7 @@ func (s *Session) Insert(values ...interface{}) (int64, error) {
 		s.CallMethod(BeforeInsert, value)
 		table := s.Model(value).RefTable() // 映射表结构
 		// 设置 INSERT INTO tableName (col1,col2,...)
-		s.clause.Set(clause.INSERT, table.Name, table.FieldNames)
+		s.clause.Set(clause.INSERT, table.Name, table.ColumnNames)
 		// 把单条结构体字段值摊平成切片，拼到 recordValues 末尾
 		recordValues = append(recordValues, table.RecordValues(value))
 	}
@@ -27,14 +43,74 @@ func (s *Session) Insert(values ...interface{}) (int64, error) {
 	return result.RowsAffected() // 返回受影响的行数
 }
 
+// InsertReturningID 插入单条记录并返回数据库生成的自增ID(value 需为指针)
+// 主键为整数类型且为零值时，插入语句会省略主键列交由数据库生成，并把生成的ID回填到主键字段
+// 依赖 sql.Result.LastInsertId，适用于 SQLite/MySQL；
+// Postgres 驱动不支持 LastInsertId，需要改用 INSERT ... RETURNING id 查询
+func (s *Session) InsertReturningID(value interface{}) (int64, error) {
+	s.CallMethod(BeforeInsert, value)
+	table := s.Model(value).RefTable()
+	columns, values := table.ColumnNames, table.RecordValues(value)
+	var pkValue reflect.Value // 需要回填的自增主键字段
+	if pk := table.PrimaryField(); pk != nil {
+		field := reflect.Indirect(reflect.ValueOf(value)).FieldByName(pk.Name)
+		if isInteger(field.Kind()) && field.IsZero() {
+			pkValue = field
+			record := values
+			columns, values = nil, nil
+			for i, f := range table.Fields { // 省略主键列
+				if f != pk {
+					columns = append(columns, table.ColumnNames[i])
+					values = append(values, record[i])
+				}
+			}
+		}
+	}
+	s.clause.Set(clause.INSERT, table.Name, columns)
+	s.clause.Set(clause.VALUES, values)
+	sql, vars := s.clause.Build(clause.INSERT, clause.VALUES)
+	result, err := s.Raw(sql, vars...).Exec()
+	if err != nil {
+		return 0, err
+	}
+	s.CallMethod(AfterInsert, nil)
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if pkValue.CanSet() {
+		if pkValue.Kind() >= reflect.Uint && pkValue.Kind() <= reflect.Uint64 {
+			pkValue.SetUint(uint64(id))
+		} else {
+			pkValue.SetInt(id)
+		}
+	}
+	return id, nil
+}
+
+// 判断是否为整数类型
+func isInteger(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Uint64
+}
+
 // 把整张表扫描进切片
 // 接收指向切片的指针
 func (s *Session) Find(values interface{}) error {
+	preloads := s.preloads // 查询结束会清空会话状态，先取出
 	s.CallMethod(BeforeQuery, nil)
 	destSlice := reflect.Indirect(reflect.ValueOf(values))                // 得到切片的反射对象
 	destType := destSlice.Type().Elem()                                   // 得到切片元素的类型
 	table := s.Model(reflect.New(destType).Elem().Interface()).RefTable() // 映射表结构
-	s.clause.Set(clause.SELECT, table.Name, table.FieldNames)
+	fields, err := s.selectedFields(table)
+	if err != nil {
+		s.Clear()
+		return err
+	}
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		columns = append(columns, field.ColumnName)
+	}
+	s.clause.Set(clause.SELECT, table.Name, columns, s.distinct)
 	sql, vars := s.clause.Build(clause.SELECT, clause.WHERE, clause.ORDERBY, clause.LIMIT)
 	rows, err := s.Raw(sql, vars...).QueryRows() // 多行数据集合
 	if err != nil {
@@ -43,9 +119,9 @@ func (s *Session) Find(values interface{}) error {
 	for rows.Next() { // 循环读取每一行
 		dest := reflect.New(destType).Elem() // 实例化元素,用于存放每一行数据
 		var values []interface{}             // 临时切片，用来存放 每个字段的指针，供 rows.Scan 写入数据
-		for _, name := range table.FieldNames {
-			// 为每个字段取地址，放进 values，供 rows.Scan 写入
-			values = append(values, dest.FieldByName(name).Addr().Interface())
+		for _, field := range fields {
+			// 为每个字段取地址，放进 values，供 rows.Scan 写入；未查询的字段保持零值
+			values = append(values, dest.FieldByName(field.Name).Addr().Interface())
 		}
 		// 将数据写入对应地址, 即 dest 的字段
 		if err := rows.Scan(values...); err != nil {
@@ -54,11 +130,110 @@ func (s *Session) Find(values interface{}) error {
 		s.CallMethod(AfterQuery, dest.Addr().Interface())
 		destSlice.Set(reflect.Append(destSlice, dest)) // 追加到切片末尾
 	}
-	return rows.Close()
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	for _, name := range preloads {
+		if err := s.preload(table, destSlice, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 指定 Find/First 后需要预加载的 has-one 关联字段，只支持单层预加载
+func (s *Session) Preload(field string) *Session {
+	s.preloads = append(s.preloads, field)
+	return s
+}
+
+// 用一次 IN 查询取出所有父记录的关联记录，按外键回填到父记录的关联字段
+func (s *Session) preload(table *schema.Schema, parents reflect.Value, name string) error {
+	rel := table.GetRelation(name)
+	if rel == nil {
+		return fmt.Errorf("unknown relation %s in table %s", name, table.Name)
+	}
+	pk := table.PrimaryField()
+	if pk == nil {
+		return fmt.Errorf("table %s has no primary key for preloading %s", table.Name, name)
+	}
+	if parents.Len() == 0 {
+		return nil
+	}
+	keys := make([]interface{}, 0, parents.Len())
+	for i := 0; i < parents.Len(); i++ {
+		keys = append(keys, parents.Index(i).FieldByName(pk.Name).Interface())
+	}
+
+	// 使用独立会话查询关联表，共享连接与事务，不影响当前会话的模型
+	sub := &Session{db: s.db, dialect: s.dialect, tx: s.tx, stmts: s.stmts, logCfg: s.logCfg, limit: -1}
+	relTable := sub.Model(reflect.New(rel.Type).Elem().Interface()).RefTable()
+	fk := relTable.GetField(rel.ForeignKey)
+	if fk == nil {
+		fk = relTable.GetFieldByColumn(rel.ForeignKey)
+	}
+	if fk == nil {
+		return fmt.Errorf("unknown foreign key %s in table %s", rel.ForeignKey, relTable.Name)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")
+	children := reflect.New(reflect.SliceOf(rel.Type))
+	if err := sub.Where(fk.ColumnName+" IN ("+placeholders+")", keys...).Find(children.Interface()); err != nil {
+		return err
+	}
+
+	byKey := make(map[string]reflect.Value, children.Elem().Len()) // 外键值 -> 关联记录
+	for i := 0; i < children.Elem().Len(); i++ {
+		child := children.Elem().Index(i)
+		byKey[fmt.Sprint(child.FieldByName(fk.Name).Interface())] = child
+	}
+	for i := 0; i < parents.Len(); i++ {
+		parent := parents.Index(i)
+		child, ok := byKey[fmt.Sprint(keys[i])]
+		if !ok {
+			continue
+		}
+		if rel.Ptr {
+			ptr := reflect.New(rel.Type)
+			ptr.Elem().Set(child)
+			child = ptr
+		}
+		parent.FieldByName(rel.FieldName).Set(child)
+	}
+	return nil
+}
+
+// 指定 Find/First 查询的列，可以是列名或字段名，未指定的字段保持零值
+func (s *Session) Select(columns ...string) *Session {
+	s.columns = append(s.columns, columns...)
+	return s
+}
+
+// 返回本次查询需要扫描的字段，顺序与 Select 指定的一致
+func (s *Session) selectedFields(table *schema.Schema) ([]*schema.Field, error) {
+	if len(s.columns) == 0 {
+		return table.Fields, nil
+	}
+	fields := make([]*schema.Field, 0, len(s.columns))
+	for _, column := range s.columns {
+		field := table.GetFieldByColumn(column)
+		if field == nil {
+			field = table.GetField(column)
+		}
+		if field == nil {
+			return nil, fmt.Errorf("unknown column %s in table %s", column, table.Name)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
 }
 
 // 支持 map[string]interface{} 形式和kv list: "Name", "Tom", "Age", 18, .... 形式的更新
+// 没有 WHERE 条件时返回 ErrGlobalUpdate，除非调用了 AllowGlobalUpdate
 func (s *Session) Update(kv ...interface{}) (int64, error) {
+	if !s.clause.Has(clause.WHERE) && !s.allowGlobalUpdate {
+		s.Clear()
+		return 0, ErrGlobalUpdate
+	}
 	s.CallMethod(BeforeUpdate, nil)
 	m, ok := kv[0].(map[string]interface{}) // 类型断言
 	if !ok {                                // 不是 map[string]interface{} 形式
@@ -69,7 +244,7 @@ func (s *Session) Update(kv ...interface{}) (int64, error) {
 			m[kv[i].(string)] = kv[i+1]
 		}
 	}
-	s.clause.Set(clause.UPDATE, s.RefTable().Name, m)
+	s.clause.Set(clause.UPDATE, s.RefTable().Name, s.columnsOf(m))
 	sql, vars := s.clause.Build(clause.UPDATE, clause.WHERE)
 	result, err := s.Raw(sql, vars...).Exec()
 	if err != nil {
@@ -79,8 +254,84 @@ func (s *Session) Update(kv ...interface{}) (int64, error) {
 	return result.RowsAffected() // 返回受影响的行数
 }
 
+// UpdateBatch 按主键批量更新多条记录，生成单条SQL:
+// UPDATE User SET Age = CASE Name WHEN ? THEN ? WHEN ? THEN ? END WHERE Name IN (?, ?)
+// values 中的每个元素为同一模型的结构体(或指针)，除主键外的所有字段都会被更新
+func (s *Session) UpdateBatch(values []interface{}) (int64, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+	s.CallMethod(BeforeUpdate, nil)
+	table := s.Model(values[0]).RefTable()
+	pk := table.PrimaryField()
+	if pk == nil {
+		return 0, errors.New("update batch requires a primary key")
+	}
+	records := make([][]interface{}, len(values)) // 每条记录的字段值
+	pkIndex := 0                                  // 主键在字段列表中的位置
+	for i, value := range values {
+		records[i] = table.RecordValues(value)
+	}
+	for i, field := range table.Fields {
+		if field == pk {
+			pkIndex = i
+		}
+	}
+	var sets []string
+	var vars []interface{}
+	for i, field := range table.Fields {
+		if field == pk {
+			continue
+		}
+		var cases strings.Builder
+		cases.WriteString(fmt.Sprintf("%s = CASE %s", field.ColumnName, pk.ColumnName))
+		for _, record := range records {
+			cases.WriteString(" WHEN ? THEN ?")
+			vars = append(vars, record[pkIndex], record[i])
+		}
+		cases.WriteString(" END")
+		sets = append(sets, cases.String())
+	}
+	if len(sets) == 0 { // 只有主键，无需更新
+		return 0, nil
+	}
+	var binds []string
+	for _, record := range records {
+		binds = append(binds, "?")
+		vars = append(vars, record[pkIndex])
+	}
+	sql := fmt.Sprintf("UPDATE %s SET %s WHERE %s IN (%s)",
+		table.Name, strings.Join(sets, ", "), pk.ColumnName, strings.Join(binds, ", "))
+	result, err := s.Raw(sql, vars...).Exec()
+	if err != nil {
+		return 0, err
+	}
+	s.CallMethod(AfterUpdate, nil)
+	return result.RowsAffected()
+}
+
+// 将以结构体字段名为键的更新映射转换为以数据库字段名为键
+// 未匹配到结构体字段的键视为列名，原样保留
+func (s *Session) columnsOf(m map[string]interface{}) map[string]interface{} {
+	table := s.RefTable()
+	columns := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if field := table.GetField(k); field != nil {
+			k = field.ColumnName
+		}
+		columns[k] = v
+	}
+	return columns
+}
+
 // 根据条件删除数据
+// 删除满足当前 WHERE 条件的记录；没有 WHERE 条件时返回 ErrGlobalDelete，
+// 除非调用了 AllowGlobalDelete
 func (s *Session) Delete() (int64, error) {
+	if !s.clause.Has(clause.WHERE) && !s.allowGlobalDelete {
+		s.Clear()
+		return 0, ErrGlobalDelete
+	}
 	s.CallMethod(BeforeDelete, nil)
 	s.clause.Set(clause.DELETE, s.RefTable().Name)
 	sql, vars := s.clause.Build(clause.DELETE, clause.WHERE)
@@ -92,9 +343,44 @@ func (s *Session) Delete() (int64, error) {
 	return result.RowsAffected() // 返回受影响的行数
 }
 
+// 允许下一条 Update 在没有 WHERE 条件时更新整张表，执行后失效
+func (s *Session) AllowGlobalUpdate() *Session {
+	s.allowGlobalUpdate = true
+	return s
+}
+
+// 允许下一条 Delete 在没有 WHERE 条件时删除整张表，执行后失效
+func (s *Session) AllowGlobalDelete() *Session {
+	s.allowGlobalDelete = true
+	return s
+}
+
+// 按主键删除单条记录，需先通过 Model 指定模型
+func (s *Session) DeleteByID(id interface{}) (int64, error) {
+	pk := s.RefTable().PrimaryField()
+	if pk == nil {
+		s.Clear()
+		return 0, fmt.Errorf("geeorm: table %s has no primary key", s.RefTable().Name)
+	}
+	return s.Where(pk.ColumnName+" = ?", id).Delete()
+}
+
 // 根据条件查询总数
 func (s *Session) Count() (int64, error) {
-	s.clause.Set(clause.COUNT, s.RefTable().Name)
+	return s.count("*")
+}
+
+// 统计某一列不重复值的数量，即 SELECT COUNT(DISTINCT column)
+func (s *Session) CountDistinct(column string) (int64, error) {
+	return s.count("DISTINCT " + column)
+}
+
+// 按聚合表达式统计记录数，支持 WHERE 条件
+func (s *Session) count(expr string) (int64, error) {
+	if err := s.takeErr(); err != nil {
+		return 0, err
+	}
+	s.clause.Set(clause.COUNT, s.RefTable().Name, expr)
 	sql, vars := s.clause.Build(clause.COUNT, clause.WHERE)
 	row := s.Raw(sql, vars...).QueryRow() // 只返回一行数据
 	var count int64
@@ -106,31 +392,170 @@ func (s *Session) Count() (int64, error) {
 
 // 限制返回的行数
 func (s *Session) Limit(num int) *Session {
-	s.clause.Set(clause.LIMIT, num)
+	s.limit = num
+	return s.setLimit()
+}
+
+// 跳过前 num 条记录，与 Limit 的调用顺序无关；只设置 Offset 时不限制行数
+func (s *Session) Offset(num int) *Session {
+	s.offset = num
+	return s.setLimit()
+}
+
+// 根据 limit 与 offset 重新生成 LIMIT 子句，多次调用 Limit/Offset 以最后一次为准，
+// 如 Limit(5).Limit(-1)、Offset(5).Offset(0) 会移除之前生成的子句
+func (s *Session) setLimit() *Session {
+	switch {
+	case s.limit >= 0 && s.offset > 0:
+		s.clause.Set(clause.LIMIT, s.limit, s.offset)
+	case s.limit >= 0:
+		s.clause.Set(clause.LIMIT, s.limit)
+	case s.offset > 0: // 只有偏移量时，不限制行数的写法因数据库而异
+		s.clause.SetSQL(clause.LIMIT, offsetOnlySQL(s.dialect), s.offset)
+	default:
+		s.clause.Remove(clause.LIMIT)
+	}
 	return s
 }
 
-// 限制条件
+// offsetOnlySQL 返回只设置偏移量的子句，方言未实现 OffsetDialect 时使用标准写法
+func offsetOnlySQL(d dialect.Dialect) string {
+	if od, ok := d.(dialect.OffsetDialect); ok {
+		return od.OffsetOnlySQL()
+	}
+	return "OFFSET ?"
+}
+
+// 限制条件，多次调用之间以 AND 连接
 func (s *Session) Where(desc string, args ...interface{}) *Session {
+	s.where = append(s.where, []condition{{desc, args}}) // 新开一个条件组
+	return s.setWhere()
+}
+
+// 按 map 生成等值条件，键为列名，条件按列名排序后以 AND 连接
+// 如 WhereMap(map[string]interface{}{"Name": "Tom", "Age": 18}) 生成 WHERE Age = ? AND Name = ?
+func (s *Session) WhereMap(conds map[string]interface{}) *Session {
+	columns := make([]string, 0, len(conds))
+	for column := range conds {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns) // map 无序，排序保证生成的 SQL 稳定
+	for _, column := range columns {
+		s.Where(column+" = ?", conds[column])
+	}
+	return s
+}
+
+// 按结构体的非零值字段生成等值条件，条件按字段声明顺序以 AND 连接，列名取自表结构映射
+func (s *Session) WhereStruct(obj interface{}) *Session {
+	value := reflect.Indirect(reflect.ValueOf(obj))
+	table := schema.Parse(obj, s.dialect)
+	for _, field := range table.Fields {
+		if fv := value.FieldByName(field.Name); !fv.IsZero() {
+			s.Where(field.ColumnName+" = ?", fv.Interface())
+		}
+	}
+	return s
+}
+
+// 或条件，与上一次 Where 的条件组成一组，组内以 OR 连接
+// 如 Where("a = ?", 1).Where("b = ?", 2).OrWhere("c = ?", 3)
+// 生成 WHERE a = ? AND (b = ? OR c = ?)
+func (s *Session) OrWhere(desc string, args ...interface{}) *Session {
+	if len(s.where) == 0 { // 之前没有条件，等同于 Where
+		return s.Where(desc, args...)
+	}
+	last := len(s.where) - 1
+	s.where[last] = append(s.where[last], condition{desc, args})
+	return s.setWhere()
+}
+
+// 将所有条件组拼接为一个 WHERE 子句，参数按条件出现的顺序排列
+func (s *Session) setWhere() *Session {
+	var groups []string
 	var vars []interface{}
-	// append(vars, desc)
-	// 把 desc 当成单个元素追加到 vars 末尾，得到：
-	// [limitVal, "Name = ? AND Age > ?"]
-	// 再 append(..., args...)
-	// 把 args 的所有元素继续追加，得到：
-	// [limitVal, "Name = ? AND Age > ?", "Tom", 18]
-	// 最后的 ...
-	// 把上一步得到的整体切片展开成可变参数，传给 clause.Set
-	s.clause.Set(clause.WHERE, append(append(vars, desc), args...)...)
+	for _, group := range s.where {
+		var descs []string
+		for _, cond := range group {
+			descs = append(descs, cond.desc)
+			vars = append(vars, cond.args...)
+		}
+		desc := strings.Join(descs, " OR ")
+		// 多个条件组时每组都加括号：单个条件本身也可能含 OR，如 Where("a = ? OR b = ?")
+		if len(s.where) > 1 {
+			desc = "(" + desc + ")"
+		}
+		groups = append(groups, desc)
+	}
+	// 把 desc 和参数展开成可变参数，传给 clause.Set，得到：
+	// ["Name = ? AND Age > ?", "Tom", 18]
+	s.clause.Set(clause.WHERE, append([]interface{}{strings.Join(groups, " AND ")}, vars...)...)
 	return s
 }
 
-// 排序
-func (s *Session) OrderBy(desc string) *Session {
-	s.clause.Set(clause.ORDERBY, desc)
+// 查询结果去重，生成 SELECT DISTINCT ...
+func (s *Session) Distinct() *Session {
+	s.distinct = true
 	return s
 }
 
+// 排序，每项为 "列名 [ASC|DESC]"，多次调用依次追加排序列，如
+// OrderBy("Age DESC").OrderBy("Name") 生成 ORDER BY Age DESC, Name
+// 不合法的排序项(非标识符的列名或未知方向)不会拼接进SQL，防止注入，
+// 并使下一次执行返回错误；需要表达式排序时使用 OrderByRaw
+func (s *Session) OrderBy(columns ...string) *Session {
+	for _, column := range columns {
+		for _, item := range strings.Split(column, ",") { // 兼容 "a, b DESC" 写法
+			order, ok := orderItem(item)
+			if !ok {
+				if s.err == nil {
+					s.err = fmt.Errorf("geeorm: invalid order by %q", strings.TrimSpace(item))
+				}
+				log.Errorf("invalid order by %q", item)
+				continue
+			}
+			s.orders = append(s.orders, order)
+		}
+	}
+	return s.setOrderBy()
+}
+
+// OrderByRaw 原样追加一个排序表达式，如 OrderByRaw("LENGTH(Name) DESC")，不做任何校验，
+// 调用方需保证 expr 不含用户输入
+func (s *Session) OrderByRaw(expr string) *Session {
+	s.orders = append(s.orders, expr)
+	return s.setOrderBy()
+}
+
+// 将所有排序项拼接为一个 ORDER BY 子句
+func (s *Session) setOrderBy() *Session {
+	if len(s.orders) > 0 {
+		s.clause.Set(clause.ORDERBY, strings.Join(s.orders, ", "))
+	}
+	return s
+}
+
+// 排序列只允许(带表名前缀的)标识符
+var orderColumn = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// 校验并规范化单个排序项 "column [ASC|DESC]"，方向不区分大小写
+func orderItem(item string) (string, bool) {
+	parts := strings.Fields(item)
+	if len(parts) == 0 || len(parts) > 2 || !orderColumn.MatchString(parts[0]) {
+		return "", false
+	}
+	if len(parts) == 1 {
+		return parts[0], true
+	}
+	dir := strings.ToUpper(parts[1])
+	if dir != "ASC" && dir != "DESC" {
+		return "", false
+	}
+	return parts[0] + " " + dir, true
+}
+
+// 查询满足条件的第一条记录，总是只取一行：会覆盖之前设置的 Limit，但保留 Offset；
+// 与其他查询一样，执行后会话的条件、排序、Limit/Offset 都会被清空，可安全复用
 func (s *Session) First(value interface{}) error {
 	dest := reflect.Indirect(reflect.ValueOf(value))              // 得到反射对象
 	destSlice := reflect.New(reflect.SliceOf(dest.Type())).Elem() // 创建一个临时的反射对象类型的切片存储结果
@@ -139,7 +564,7 @@ func (s *Session) First(value interface{}) error {
 		return err
 	}
 	if destSlice.Len() == 0 {
-		return errors.New("NOT FOUND")
+		return ErrRecordNotFound
 	}
 	dest.Set(destSlice.Index(0)) // 将第一个元素赋值给 value
 	return nil
@@ -1,6 +1,13 @@
 ﻿package session
 
-import "testing"
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nukecoke1828/7daysProgram/Geeorm/clause"
+)
 
 var (
 	user1 = &User{"Tom", 18}
@@ -28,6 +35,18 @@ func TestSession_Insert(t *testing.T) {
 	}
 }
 
+func TestSession_InsertReturningID(t *testing.T) {
+	s := NewSession().Model(&Member{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Insert(&Member{1, "Tom"})
+	m := &Member{UserName: "Sam"}
+	id, err := s.InsertReturningID(m)
+	if err != nil || id != 2 || m.ID != 2 {
+		t.Fatal("failed to insert returning id, got", id, m.ID, err)
+	}
+}
+
 func TestSession_Find(t *testing.T) {
 	s := testRecordInit(t)
 	var users []User
@@ -45,6 +64,222 @@ func TestSession_Limit(t *testing.T) {
 	}
 }
 
+type Score struct {
+	Value int
+}
+
+func TestSession_Distinct(t *testing.T) {
+	s := NewSession().Model(&Score{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Insert(&Score{60}, &Score{90}, &Score{90}, &Score{100})
+	s.Distinct().Where("Value > ?", 60).OrderBy("Value").Limit(5)
+	s.clause.Set(clause.SELECT, "Score", []string{"Value"}, s.distinct)
+	sql, vars := s.clause.Build(clause.SELECT, clause.WHERE, clause.ORDERBY, clause.LIMIT)
+	if sql != "SELECT DISTINCT Value FROM Score WHERE Value > ? ORDER BY Value LIMIT ?" || !reflect.DeepEqual(vars, []interface{}{60, 5}) {
+		t.Fatal("failed to build distinct query, got", sql, vars)
+	}
+	var scores []Score
+	if err := s.Find(&scores); err != nil || !reflect.DeepEqual(scores, []Score{{90}, {100}}) {
+		t.Fatal("failed to find distinct records, got", scores, err)
+	}
+}
+
+func TestSession_CountDistinct(t *testing.T) {
+	s := NewSession().Model(&Score{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Insert(&Score{60}, &Score{90}, &Score{90}, &Score{100}, &Score{100})
+	if count, err := s.CountDistinct("Value"); err != nil || count != 3 {
+		t.Fatal("failed to count distinct, got", count, err)
+	}
+	if count, err := s.Where("Value > ?", 60).CountDistinct("Value"); err != nil || count != 2 {
+		t.Fatal("failed to count distinct with where, got", count, err)
+	}
+}
+
+func TestSession_Select(t *testing.T) {
+	s := testRecordInit(t)
+	var users []User
+	if err := s.Select("Name").OrderBy("Age").Find(&users); err != nil {
+		t.Fatal("failed to find with selected columns", err)
+	}
+	if !reflect.DeepEqual(users, []User{{Name: "Tom"}, {Name: "Sam"}}) {
+		t.Fatal("only selected columns should be populated, got", users)
+	}
+	u := &User{}
+	if err := s.Select("Age").Where("Name = ?", "Sam").First(u); err != nil || *u != (User{Age: 25}) {
+		t.Fatal("failed to query first with selected columns, got", u, err)
+	}
+	if err := s.Select("Unknown").Find(&users); err == nil {
+		t.Fatal("expect error for unknown column")
+	}
+}
+
+func TestSession_ToSQL(t *testing.T) {
+	s := testRecordInit(t)
+	s.Select("Name").Where("Age > ?", 18).OrWhere("Name = ?", "Tom").OrderBy("Age DESC").Limit(2)
+	sql, vars := s.ToSQL()
+	if sql != "SELECT Name FROM User WHERE Age > ? OR Name = ? ORDER BY Age DESC LIMIT ?" || !reflect.DeepEqual(vars, []interface{}{18, "Tom", 2}) {
+		t.Fatal("failed to build select sql, got", sql, vars)
+	}
+	var users []User
+	if err := s.Find(&users); err != nil || len(users) != 2 {
+		t.Fatal("ToSQL shouldn't change session state", users, err)
+	}
+
+	if n, err := s.DryRun().Where("Name = ?", "Tom").Update("Age", 30); err != nil || n != 0 {
+		t.Fatal("dry run update should not fail", n, err)
+	}
+	sql, vars = s.DryRunSQL()
+	if sql != "UPDATE User SET Age= ? WHERE Name = ?" || !reflect.DeepEqual(vars, []interface{}{30, "Tom"}) {
+		t.Fatal("failed to build update sql, got", sql, vars)
+	}
+	if _, err := s.DryRun().Insert(&User{"Jack", 25}); err != nil {
+		t.Fatal("dry run insert should not fail", err)
+	}
+	if sql, vars = s.DryRunSQL(); sql != "INSERT INTO User (Name, Age) VALUES (?, ?)" || !reflect.DeepEqual(vars, []interface{}{"Jack", 25}) {
+		t.Fatal("failed to build insert sql, got", sql, vars)
+	}
+	// DryRun 只作用于下一条语句，且不会写入数据库
+	u := &User{}
+	if err := s.Where("Name = ?", "Tom").First(u); err != nil || u.Age != 18 {
+		t.Fatal("dry run should not execute update, got", u, err)
+	}
+	if n, err := s.Count(); err != nil || n != 2 {
+		t.Fatal("dry run should not execute insert, got", n, err)
+	}
+	if n, err := s.Where("Name = ?", "Tom").Delete(); err != nil || n != 1 {
+		t.Fatal("dry run should only apply to the next statement", n, err)
+	}
+}
+
+func TestSession_LimitOffset(t *testing.T) {
+	s := testRecordInit(t)
+	_, _ = s.Insert(user3)
+	var users []User
+	// Offset 与 Limit 的调用顺序无关，重复调用以最后一次为准
+	if err := s.Offset(1).Limit(5).OrderBy("Name").Limit(1).Find(&users); err != nil || len(users) != 1 || users[0].Name != "Sam" {
+		t.Fatal("failed to query with limit and offset, got", users, err)
+	}
+	users = nil
+	if err := s.Offset(2).OrderBy("Name").Find(&users); err != nil || len(users) != 1 || users[0].Name != "Tom" {
+		t.Fatal("failed to query with offset only, got", users, err)
+	}
+
+	// 取消 Limit/Offset 时移除之前生成的子句
+	s.Limit(5).Limit(-1)
+	if sql, vars := s.ToSQL(); sql != "SELECT Name, Age FROM User" || len(vars) != 0 {
+		t.Fatal("expect limit removed, got", sql, vars)
+	}
+	s.Offset(5).Offset(0)
+	if sql, vars := s.ToSQL(); sql != "SELECT Name, Age FROM User" || len(vars) != 0 {
+		t.Fatal("expect offset removed, got", sql, vars)
+	}
+	s.Offset(1).Limit(2).Limit(-1)
+	if sql, vars := s.ToSQL(); sql != "SELECT Name, Age FROM User LIMIT -1 OFFSET ?" || !reflect.DeepEqual(vars, []interface{}{1}) {
+		t.Fatal("expect offset-only clause from dialect, got", sql, vars)
+	}
+	users = nil
+	if err := s.OrderBy("Name").Find(&users); err != nil || len(users) != 2 || users[0].Name != "Sam" {
+		t.Fatal("failed to query with offset after clearing limit, got", users, err)
+	}
+	if got := offsetOnlySQL(nil); got != "OFFSET ?" {
+		t.Fatal("expect standard offset clause without OffsetDialect, got", got)
+	}
+
+	// First 覆盖用户设置的 Limit 但保留 Offset
+	u := &User{}
+	if err := s.Limit(3).Offset(1).OrderBy("Name").First(u); err != nil || u.Name != "Sam" {
+		t.Fatal("failed to query first with offset, got", u, err)
+	}
+	// First 之后会话状态被清空，之前的 Limit/Offset 不影响后续查询
+	users = nil
+	if err := s.Find(&users); err != nil || len(users) != 3 {
+		t.Fatal("expect session reset after First, got", users, err)
+	}
+}
+
+func TestSession_OrderBy(t *testing.T) {
+	s := testRecordInit(t)
+	_, _ = s.Insert(user3)
+	s.OrderBy("Age desc").OrderBy("Name ASC", "Age; DROP TABLE User", "Name SIDEWAYS")
+	sql, _ := s.ToSQL()
+	if sql != "SELECT Name, Age FROM User ORDER BY Age DESC, Name ASC" {
+		t.Fatal("failed to build multi-column order by, got", sql)
+	}
+	var users []User
+	if err := s.Find(&users); err == nil || !strings.Contains(err.Error(), "Age; DROP TABLE User") {
+		t.Fatal("expect invalid order by to fail the query, got", users, err)
+	}
+	if n, err := s.Count(); err != nil || n != 3 {
+		t.Fatal("invalid order by should be cleared after the failed query", n, err)
+	}
+	// QueryRow 同样不会丢掉排序错误后执行无序查询
+	var name string
+	if err := s.OrderBy("Name SIDEWAYS").Raw("SELECT Name FROM User").QueryRow().Scan(&name); err == nil || name != "" {
+		t.Fatal("expect invalid order by to fail QueryRow, got", name, err)
+	}
+	if err := s.OrderBy("Name SIDEWAYS").Raw("SELECT Name FROM User").ScanRow(&name); err == nil || !strings.Contains(err.Error(), "Name SIDEWAYS") {
+		t.Fatal("expect ScanRow to return the order by error, got", name, err)
+	}
+	if err := s.Raw("SELECT Name FROM User ORDER BY Name").ScanRow(&name); err != nil || name != "Jack" {
+		t.Fatal("failed to scan row after the failed query", name, err)
+	}
+	s.OrderBy("Age desc").OrderBy("Name ASC")
+	if err := s.Find(&users); err != nil || len(users) != 3 || users[0].Name != "Jack" || users[1].Name != "Sam" || users[2].Name != "Tom" {
+		t.Fatal("failed to order by multiple columns, got", users, err)
+	}
+	sql, _ = s.OrderBy("Name").ToSQL()
+	if sql != "SELECT Name, Age FROM User ORDER BY Name" {
+		t.Fatal("order by should be reset after query, got", sql)
+	}
+	s.Clear()
+}
+
+func TestSession_OrderByRaw(t *testing.T) {
+	s := testRecordInit(t)
+	_, _ = s.Insert(user3)
+	s.OrderByRaw("LENGTH(Name) DESC").OrderBy("Name")
+	sql, _ := s.ToSQL()
+	if sql != "SELECT Name, Age FROM User ORDER BY LENGTH(Name) DESC, Name" {
+		t.Fatal("failed to build raw order by, got", sql)
+	}
+	var users []User
+	if err := s.Find(&users); err != nil || len(users) != 3 || users[0].Name != "Jack" || users[1].Name != "Sam" || users[2].Name != "Tom" {
+		t.Fatal("failed to order by raw expression, got", users, err)
+	}
+}
+
+func TestSession_WhereMap(t *testing.T) {
+	s := testRecordInit(t)
+	s.WhereMap(map[string]interface{}{"Name": "Tom", "Age": 18})
+	sql, vars := s.ToSQL()
+	if sql != "SELECT Name, Age FROM User WHERE (Age = ?) AND (Name = ?)" || !reflect.DeepEqual(vars, []interface{}{18, "Tom"}) {
+		t.Fatal("failed to build where from map, got", sql, vars)
+	}
+	var users []User
+	if err := s.Find(&users); err != nil || len(users) != 1 || users[0].Name != "Tom" {
+		t.Fatal("failed to query with map conditions", users, err)
+	}
+}
+
+func TestSession_WhereStruct(t *testing.T) {
+	s := testRecordInit(t)
+	s.WhereStruct(&User{Age: 25}).OrderBy("Name")
+	sql, vars := s.ToSQL()
+	if sql != "SELECT Name, Age FROM User WHERE Age = ? ORDER BY Name" || !reflect.DeepEqual(vars, []interface{}{25}) {
+		t.Fatal("failed to build where from struct, got", sql, vars)
+	}
+	s.Clear()
+	s.WhereStruct(User{Name: "Sam", Age: 25})
+	sql, vars = s.ToSQL()
+	if sql != "SELECT Name, Age FROM User WHERE (Name = ?) AND (Age = ?)" || !reflect.DeepEqual(vars, []interface{}{"Sam", 25}) {
+		t.Fatal("failed to build where from struct, got", sql, vars)
+	}
+	s.Clear()
+}
+
 func TestSession_Update(t *testing.T) {
 	s := testRecordInit(t)
 	affected, _ := s.Where("Name = ?", "Tom").Update("Age", 30)
@@ -56,6 +291,41 @@ func TestSession_Update(t *testing.T) {
 	}
 }
 
+func TestSession_UpdateBatch(t *testing.T) {
+	s := NewSession().Model(&Member{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Insert(&Member{1, "Tom"}, &Member{2, "Sam"}, &Member{3, "Jack"})
+	affected, err := s.UpdateBatch([]interface{}{&Member{1, "Tom2"}, &Member{2, "Sam2"}, Member{3, "Jack2"}})
+	if err != nil || affected != 3 {
+		t.Fatal("failed to update batch", affected, err)
+	}
+	var members []Member
+	_ = s.OrderBy("id").Find(&members)
+	if !reflect.DeepEqual(members, []Member{{1, "Tom2"}, {2, "Sam2"}, {3, "Jack2"}}) {
+		t.Fatal("failed to update each record, got", members)
+	}
+}
+
+func TestSession_GlobalUpdate(t *testing.T) {
+	s := testRecordInit(t)
+	if _, err := s.Update("Age", 30); !errors.Is(err, ErrGlobalUpdate) || !strings.Contains(err.Error(), "unconditional update blocked") {
+		t.Fatal("expect unconditional update blocked, got", err)
+	}
+	if count, _ := s.Where("Age = ?", 30).Count(); count != 0 {
+		t.Fatal("expect no rows updated, got", count)
+	}
+	if affected, err := s.Where("Name = ?", "Sam").Update("Age", 30); err != nil || affected != 1 {
+		t.Fatal("failed to update with where", affected, err)
+	}
+	if affected, err := s.AllowGlobalUpdate().Update("Age", 40); err != nil || affected != 2 {
+		t.Fatal("failed to update all rows with override", affected, err)
+	}
+	if _, err := s.Update("Age", 50); !errors.Is(err, ErrGlobalUpdate) {
+		t.Fatal("expect override to be reset, got", err)
+	}
+}
+
 func TestSession_DeleteAndCount(t *testing.T) {
 	s := testRecordInit(t)
 	affected, _ := s.Where("Name = ?", "Tom").Delete()
@@ -65,3 +335,133 @@ func TestSession_DeleteAndCount(t *testing.T) {
 		t.Fatal("failed to delete or count")
 	}
 }
+
+func TestSession_DeleteByID(t *testing.T) {
+	s := NewSession().Model(&Member{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Insert(&Member{1, "Tom"}, &Member{2, "Sam"})
+	if affected, err := s.DeleteByID(2); err != nil || affected != 1 {
+		t.Fatal("failed to delete by id", affected, err)
+	}
+	var members []Member
+	if err := s.Find(&members); err != nil || len(members) != 1 || members[0].ID != 1 {
+		t.Fatal("expect only member 1 left, got", members, err)
+	}
+}
+
+func TestSession_GlobalDelete(t *testing.T) {
+	s := testRecordInit(t)
+	if _, err := s.Delete(); !errors.Is(err, ErrGlobalDelete) {
+		t.Fatal("expect unconditional delete blocked, got", err)
+	}
+	if count, _ := s.Count(); count != 2 {
+		t.Fatal("expect no rows deleted, got count", count)
+	}
+	if affected, err := s.AllowGlobalDelete().Delete(); err != nil || affected != 2 {
+		t.Fatal("failed to delete all rows with override", affected, err)
+	}
+	// 覆盖只对一次删除生效
+	_, _ = s.Insert(user1)
+	if _, err := s.Delete(); !errors.Is(err, ErrGlobalDelete) {
+		t.Fatal("expect override to be reset, got", err)
+	}
+}
+
+func TestSession_WhereChain(t *testing.T) {
+	s := testRecordInit(t)
+	s.Where("Name = ?", "Tom").Where("Age = ?", 18)
+	sql, vars := s.clause.Build(clause.WHERE)
+	if sql != "WHERE (Name = ?) AND (Age = ?)" || !reflect.DeepEqual(vars, []interface{}{"Tom", 18}) {
+		t.Fatal("failed to chain where, got", sql, vars)
+	}
+	s.Clear()
+	if affected, _ := s.Where("Name = ?", "Tom").Where("Age = ?", 25).Delete(); affected != 0 {
+		t.Fatal("expect first where to be kept, but deleted", affected)
+	}
+	if affected, _ := s.Where("Name = ?", "Tom").Where("Age = ?", 18).Delete(); affected != 1 {
+		t.Fatal("failed to delete with chained where")
+	}
+}
+
+func TestSession_WhereRawOr(t *testing.T) {
+	s := testRecordInit(t)
+	s.Where("Name = ? OR Name = ?", "Tom", "Sam").Where("Age = ?", 25)
+	sql, vars := s.clause.Build(clause.WHERE)
+	if sql != "WHERE (Name = ? OR Name = ?) AND (Age = ?)" || !reflect.DeepEqual(vars, []interface{}{"Tom", "Sam", 25}) {
+		t.Fatal("a single condition containing OR should be parenthesized, got", sql, vars)
+	}
+	var users []User
+	if err := s.Find(&users); err != nil || len(users) != 1 || users[0].Name != "Sam" {
+		t.Fatal("expect only Sam to match, got", users, err)
+	}
+}
+
+func TestSession_OrWhere(t *testing.T) {
+	s := testRecordInit(t)
+	_, _ = s.Insert(user3)
+	s.Where("Age = ?", 25).Where("Name = ?", "Sam").OrWhere("Name = ?", "Jack")
+	sql, vars := s.clause.Build(clause.WHERE)
+	if sql != "WHERE (Age = ?) AND (Name = ? OR Name = ?)" || !reflect.DeepEqual(vars, []interface{}{25, "Sam", "Jack"}) {
+		t.Fatal("failed to build or group, got", sql, vars)
+	}
+	var users []User
+	if err := s.Find(&users); err != nil || len(users) != 2 {
+		t.Fatal("failed to query with or group")
+	}
+	count, _ := s.Where("Age = ?", 18).Where("Name = ?", "Sam").OrWhere("Name = ?", "Jack").Count()
+	if count != 0 {
+		t.Fatal("failed to and or group, got", count)
+	}
+}
+
+type Person struct {
+	ID      int `geeorm:"PRIMARY KEY"`
+	Name    string
+	Profile *Profile `geeorm:"foreignkey:PersonID"`
+}
+
+type Profile struct {
+	ID       int `geeorm:"PRIMARY KEY"`
+	PersonID int
+	Bio      string
+}
+
+var profileQueries int
+
+func (Profile) BeforeQuery(s *Session) error {
+	profileQueries++
+	return nil
+}
+
+func TestSession_Preload(t *testing.T) {
+	s := NewSession().Model(&Person{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Insert(&Person{ID: 1, Name: "Tom"}, &Person{ID: 2, Name: "Sam"}, &Person{ID: 3, Name: "Jack"})
+	ps := NewSession().Model(&Profile{})
+	_ = ps.DropTable()
+	_ = ps.CreateTable()
+	_, _ = ps.Insert(&Profile{1, 1, "tom's bio"}, &Profile{2, 2, "sam's bio"})
+
+	profileQueries = 0
+	var people []Person
+	if err := s.Preload("Profile").OrderBy("ID").Find(&people); err != nil {
+		t.Fatal(err)
+	}
+	if profileQueries != 1 {
+		t.Fatal("expect one extra query, got", profileQueries)
+	}
+	if len(people) != 3 || people[0].Profile == nil || people[0].Profile.Bio != "tom's bio" ||
+		people[1].Profile == nil || people[1].Profile.Bio != "sam's bio" || people[2].Profile != nil {
+		t.Fatalf("failed to preload profiles, got %+v", people)
+	}
+
+	var p Person
+	if err := s.Preload("Profile").Where("Name = ?", "Sam").First(&p); err != nil || p.Profile == nil || p.Profile.PersonID != 2 {
+		t.Fatalf("failed to preload with First, got %+v %v", p, err)
+	}
+	if err := s.Preload("Unknown").Find(&people); err == nil {
+		t.Fatal("expect error for unknown relation")
+	}
+}
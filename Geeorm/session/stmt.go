@@ -0,0 +1,93 @@
+﻿package session
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/nukecoke1828/7daysProgram/Geeorm/log"
+)
+
+var _ CommonDB = (*StmtCache)(nil) // 确保 StmtCache 实现 CommonDB 接口
+
+// StmtCache 预编译语句缓存，以SQL字符串为键复用 *sql.Stmt，可在多个会话间并发共享
+type StmtCache struct {
+	db    *sql.DB
+	mu    sync.RWMutex
+	stmts map[string]*sql.Stmt
+}
+
+// NewStmtCache 创建一个预编译语句缓存
+func NewStmtCache(db *sql.DB) *StmtCache {
+	return &StmtCache{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+}
+
+// prepare 获取SQL对应的预编译语句，不存在时才真正调用 db.Prepare
+func (c *StmtCache) prepare(query string) (*sql.Stmt, error) {
+	c.mu.RLock()
+	stmt, ok := c.stmts[query]
+	c.mu.RUnlock()
+	if ok {
+		return stmt, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stmt, ok = c.stmts[query]; ok { // 双重检查，防止并发重复预编译
+		return stmt, nil
+	}
+	stmt, err := c.db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+func (c *StmtCache) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := c.prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Query(args...)
+}
+
+// QueryRow 预编译失败时退回到直接查询，由 *sql.Row 携带错误
+func (c *StmtCache) QueryRow(query string, args ...interface{}) *sql.Row {
+	stmt, err := c.prepare(query)
+	if err != nil {
+		return c.db.QueryRow(query, args...)
+	}
+	return stmt.QueryRow(args...)
+}
+
+func (c *StmtCache) Exec(query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := c.prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Exec(args...)
+}
+
+// Len 返回已缓存的预编译语句数量
+func (c *StmtCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.stmts)
+}
+
+// Close 关闭并清空所有预编译语句
+func (c *StmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var err error
+	for query, stmt := range c.stmts {
+		if e := stmt.Close(); e != nil {
+			log.Error(e)
+			err = e
+		}
+		delete(c.stmts, query)
+	}
+	return err
+}
@@ -0,0 +1,36 @@
+﻿package session
+
+import "testing"
+
+func TestStmtCache(t *testing.T) {
+	cache := NewStmtCache(TestDB)
+	defer cache.Close()
+	s := NewSession().UseStmtCache(cache)
+	_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
+	_, _ = s.Raw("CREATE TABLE User(Name text);").Exec()
+	for i := 0; i < 10; i++ {
+		if _, err := s.Raw("INSERT INTO User(`Name`) values (?)", "Tom").Exec(); err != nil {
+			t.Fatal("failed to exec with prepared statement", err)
+		}
+	}
+	var count int
+	if err := s.Raw("SELECT count(*) FROM User").QueryRow().Scan(&count); err != nil || count != 10 {
+		t.Fatal("failed to query with prepared statement", count, err)
+	}
+	if cache.Len() != 4 {
+		t.Fatal("expect each statement to be prepared once, got", cache.Len())
+	}
+}
+
+func BenchmarkStmtCache(b *testing.B) {
+	cache := NewStmtCache(TestDB)
+	defer cache.Close()
+	s := NewSession().UseStmtCache(cache)
+	_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
+	_, _ = s.Raw("CREATE TABLE User(Name text);").Exec()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var count int
+		_ = s.Raw("SELECT count(*) FROM User").QueryRow().Scan(&count)
+	}
+}
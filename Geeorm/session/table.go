@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/nukecoke1828/7daysProgram/Geeorm/dialect"
 	"github.com/nukecoke1828/7daysProgram/Geeorm/log"
 	"github.com/nukecoke1828/7daysProgram/Geeorm/schema"
 )
@@ -26,15 +27,113 @@ func (s *Session) RefTable() *schema.Schema {
 	return s.refTable
 }
 
+// 获取会话使用的数据库方言
+func (s *Session) Dialect() dialect.Dialect {
+	return s.dialect
+}
+
 func (s *Session) CreateTable() error {
 	table := s.RefTable()
-	var columns []string                 // 字段列表(字段名 字段类型 标签)
+	var columns []string                 // 字段列表(字段名 字段类型 约束)
 	for _, field := range table.Fields { // 将表中的字段信息转换为SQL语句中的字段列表
-		columns = append(columns, fmt.Sprintf("%s %s %s", field.Name, field.Type, field.Tag))
+		columns = append(columns, columnDefinition(s.dialect, field))
 	}
 	desc := strings.Join(columns, ", ") // 将字段列表用逗号分隔
-	_, err := s.Raw(fmt.Sprintf("CREATE TABLE %s (%s);", table.Name, desc)).Exec()
-	return err
+	s.CallMethod(BeforeCreateTable, nil)
+	if _, err := s.Raw(fmt.Sprintf("CREATE TABLE %s (%s);", table.Name, desc)).Exec(); err != nil {
+		return err
+	}
+	for _, sql := range s.indexSQLs(table) { // 建表后建立 tag 声明的索引
+		if _, err := s.Raw(sql).Exec(); err != nil {
+			return err
+		}
+	}
+	s.CallMethod(AfterCreateTable, nil) // 建表成功后触发，可用于建索引、写入初始数据
+	return nil
+}
+
+// 生成 tag 声明的建索引语句，索引名为 idx_表名_列名
+// 方言实现了 IndexDialect 时使用方言的语句
+func (s *Session) indexSQLs(table *schema.Schema) []string {
+	d, ok := s.dialect.(dialect.IndexDialect)
+	var sqls []string
+	for _, field := range table.IndexFields {
+		name := fmt.Sprintf("idx_%s_%s", table.Name, field.ColumnName)
+		switch {
+		case ok:
+			sqls = append(sqls, d.CreateIndexSQL(table.Name, name, field.ColumnName, field.UniqueIndex))
+		case field.UniqueIndex:
+			sqls = append(sqls, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s);", name, table.Name, field.ColumnName))
+		default:
+			sqls = append(sqls, fmt.Sprintf("CREATE INDEX %s ON %s (%s);", name, table.Name, field.ColumnName))
+		}
+	}
+	return sqls
+}
+
+// 生成单个字段的定义，如 "Age integer NOT NULL DEFAULT 0"
+// 方言实现了 ConstraintDialect 时由方言拼写约束部分
+func columnDefinition(d dialect.Dialect, field *schema.Field) string {
+	parts := []string{field.ColumnName, field.Type}
+	if field.Tag != "" {
+		parts = append(parts, field.Tag)
+	}
+	var constraints string
+	if cd, ok := d.(dialect.ConstraintDialect); ok {
+		constraints = cd.ColumnConstraintSQL(field.NotNull, field.Unique, field.Default)
+	} else {
+		constraints = dialect.StandardConstraintSQL(field.NotNull, field.Unique, field.Default)
+	}
+	if constraints != "" {
+		parts = append(parts, constraints)
+	}
+	return strings.Join(parts, " ")
+}
+
+// MigrateColumnTypes 对比现有字段类型与结构体映射的类型(方言实现了 ColumnTypeDialect 时)，
+// 有字段类型变化时执行方言生成的修改语句，字段约束与索引由方言负责保留
+func (s *Session) MigrateColumnTypes() error {
+	d, ok := s.dialect.(dialect.ColumnTypeDialect)
+	if !ok {
+		return nil
+	}
+	table := s.RefTable()
+	query, args := d.ColumnTypesSQL(table.Name)
+	rows, err := s.Raw(query, args...).QueryRows()
+	if err != nil {
+		return err
+	}
+	current := make(map[string]string) // 字段名 -> 现有类型
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		current[name] = typ
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	change := dialect.ColumnTypeChange{Table: table.Name, Changed: make(map[string]string)}
+	for _, field := range table.Fields {
+		change.Columns = append(change.Columns, field.ColumnName)
+		change.Definitions = append(change.Definitions, columnDefinition(s.dialect, field))
+		if typ, ok := current[field.ColumnName]; ok && !strings.EqualFold(typ, field.Type) {
+			log.Infof("column %s type changed from %s to %s", field.ColumnName, typ, field.Type)
+			change.Changed[field.ColumnName] = field.Type
+		}
+	}
+	if len(change.Changed) == 0 {
+		return nil
+	}
+	change.Indexes = s.indexSQLs(table)
+	for _, sql := range d.AlterColumnTypesSQL(change) {
+		if _, err := s.Raw(sql).Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *Session) DropTable() error {
@@ -1,6 +1,13 @@
 ﻿package session
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nukecoke1828/7daysProgram/Geeorm/dialect"
+	"github.com/nukecoke1828/7daysProgram/Geeorm/schema"
+)
 
 type User struct {
 	Name string `geeorm:"PRIMARY KEY"`
@@ -15,3 +22,141 @@ func TestSession_CreateTable(t *testing.T) {
 		t.Fatal("Failed to create table User")
 	}
 }
+
+type Member struct {
+	ID       int    `geeorm:"PRIMARY KEY;column:id"`
+	UserName string `geeorm:"column:user_name"`
+}
+
+func TestSession_ColumnName(t *testing.T) {
+	s := NewSession().Model(&Member{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	var ddl string
+	_ = s.Raw("SELECT sql FROM sqlite_master WHERE type='table' and name = ?", "Member").QueryRow().Scan(&ddl)
+	if ddl != "CREATE TABLE Member (id integer PRIMARY KEY, user_name text)" {
+		t.Fatal("failed to create table with column name, got", ddl)
+	}
+	if _, err := s.Insert(&Member{1, "Tom"}); err != nil {
+		t.Fatal("failed to insert with column name", err)
+	}
+	var members []Member
+	if err := s.Find(&members); err != nil || !reflect.DeepEqual(members, []Member{{1, "Tom"}}) {
+		t.Fatal("failed to find with column name, got", members, err)
+	}
+}
+
+type Product struct {
+	Code  string `geeorm:"not null;unique"`
+	Price int    `geeorm:"default:0"`
+	Stock int    `geeorm:"NOT NULL;default:10"`
+}
+
+func TestSession_CreateTableConstraints(t *testing.T) {
+	s := NewSession().Model(&Product{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	var ddl string
+	_ = s.Raw("SELECT sql FROM sqlite_master WHERE type='table' and name = ?", "Product").QueryRow().Scan(&ddl)
+	expect := "CREATE TABLE Product (Code text NOT NULL UNIQUE, Price integer DEFAULT 0, Stock integer NOT NULL DEFAULT 10)"
+	if ddl != expect {
+		t.Fatal("failed to create table with constraints, got", ddl)
+	}
+}
+
+// quotedDefaultDialect 给默认值加引号的方言，用于验证约束经由 ConstraintDialect 生成
+type quotedDefaultDialect struct {
+	dialect.Dialect
+}
+
+func (quotedDefaultDialect) ColumnConstraintSQL(notNull, unique bool, defaultValue string) string {
+	if defaultValue != "" {
+		defaultValue = "'" + defaultValue + "'"
+	}
+	return dialect.StandardConstraintSQL(notNull, unique, defaultValue)
+}
+
+func TestColumnDefinition_ConstraintDialect(t *testing.T) {
+	table := schema.Parse(&Product{}, TestDial)
+	var std, quoted []string
+	for _, field := range table.Fields {
+		std = append(std, columnDefinition(TestDial, field))
+		quoted = append(quoted, columnDefinition(quotedDefaultDialect{TestDial}, field))
+	}
+	if expect := []string{"Code text NOT NULL UNIQUE", "Price integer DEFAULT 0", "Stock integer NOT NULL DEFAULT 10"}; !reflect.DeepEqual(std, expect) {
+		t.Fatal("unexpected sqlite column definitions", std)
+	}
+	if expect := []string{"Code text NOT NULL UNIQUE", "Price integer DEFAULT '0'", "Stock integer NOT NULL DEFAULT '10'"}; !reflect.DeepEqual(quoted, expect) {
+		t.Fatal("constraints should be spelled by the dialect, got", quoted)
+	}
+}
+
+type Model struct {
+	ID        int `geeorm:"PRIMARY KEY"`
+	CreatedAt time.Time
+}
+
+type Article struct {
+	Model
+	Title string
+}
+
+func TestSession_Embedded(t *testing.T) {
+	s := NewSession().Model(&Article{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	var ddl string
+	_ = s.Raw("SELECT sql FROM sqlite_master WHERE type='table' and name = ?", "Article").QueryRow().Scan(&ddl)
+	if ddl != "CREATE TABLE Article (ID integer PRIMARY KEY, CreatedAt datetime, Title text)" {
+		t.Fatal("failed to create table with embedded struct, got", ddl)
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := s.Insert(&Article{Model{1, now}, "Hello"}); err != nil {
+		t.Fatal("failed to insert with embedded struct", err)
+	}
+	var articles []Article
+	if err := s.Find(&articles); err != nil || len(articles) != 1 {
+		t.Fatal("failed to find with embedded struct", err)
+	}
+	if a := articles[0]; a.ID != 1 || !a.CreatedAt.Equal(now) || a.Title != "Hello" {
+		t.Fatal("failed to round-trip embedded struct, got", a)
+	}
+}
+
+type Customer struct {
+	Email string `geeorm:"unique_index"`
+	Age   int    `geeorm:"index"`
+}
+
+func TestSession_CreateTableIndex(t *testing.T) {
+	s := NewSession().Model(&Customer{})
+	expect := []string{
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_Customer_Email ON Customer (Email);",
+		"CREATE INDEX IF NOT EXISTS idx_Customer_Age ON Customer (Age);",
+	}
+	if sqls := s.indexSQLs(s.RefTable()); !reflect.DeepEqual(sqls, expect) {
+		t.Fatal("failed to generate index DDL, got", sqls)
+	}
+
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := s.Raw("SELECT name FROM sqlite_master WHERE type='index' and tbl_name = ? ORDER BY name", "Customer").QueryRows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = rows.Close() }()
+	var names []string
+	for rows.Next() {
+		var name string
+		_ = rows.Scan(&name)
+		names = append(names, name)
+	}
+	if !reflect.DeepEqual(names, []string{"idx_Customer_Age", "idx_Customer_Email"}) {
+		t.Fatal("failed to create indexes, got", names)
+	}
+	if _, err := s.Insert(&Customer{"a@b.c", 1}, &Customer{"a@b.c", 2}); err == nil {
+		t.Fatal("expect unique index violation")
+	}
+}
@@ -1,18 +1,79 @@
 ﻿package session
 
-import "github.com/nukecoke1828/7daysProgram/Geeorm/log"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
 
-func (s *Session) Begin() (err error) {
+	"github.com/nukecoke1828/7daysProgram/Geeorm/dialect"
+	"github.com/nukecoke1828/7daysProgram/Geeorm/log"
+)
+
+// ErrNoTransaction 在没有开启事务的会话上提交或回滚
+var ErrNoTransaction = errors.New("geeorm: no active transaction")
+
+// Begin 开启事务，可选传入 *sql.TxOptions 指定隔离级别或只读
+func (s *Session) Begin(opts ...*sql.TxOptions) error {
+	var opt *sql.TxOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	return s.BeginTx(context.Background(), opt)
+}
+
+// BeginTx 以 ctx 开启事务，ctx 取消时驱动会回滚事务；opts 为 nil 时使用默认选项。
+// 事务结束前会话上的所有操作都在该事务中执行，需调用 Commit 或 Rollback 结束事务
+func (s *Session) BeginTx(ctx context.Context, opts *sql.TxOptions) (err error) {
+	if s.tx != nil {
+		return errors.New("geeorm: transaction already begun")
+	}
 	log.Info("Begin transaction")
-	if s.tx, err = s.db.Begin(); err != nil {
+	if s.tx, err = s.db.BeginTx(ctx, opts); err != nil {
 		log.Error(err)
 		return
 	}
+	if opts != nil && opts.ReadOnly {
+		if err = s.setReadOnly(true); err != nil {
+			_ = s.tx.Rollback() // 无法保证只读，放弃该事务
+			s.endTx()
+		}
+	}
 	return
 }
 
+// endTx 事务结束后清理事务状态，之后的操作重新使用连接池
+func (s *Session) endTx() {
+	s.tx = nil
+	s.readOnly = false
+}
+
+// setReadOnly 对忽略只读选项的驱动，在事务连接上切换只读模式
+func (s *Session) setReadOnly(readOnly bool) error {
+	d, ok := s.dialect.(dialect.ReadOnlyDialect)
+	if !ok {
+		return nil
+	}
+	s.readOnly = readOnly
+	if _, err := s.tx.Exec(d.ReadOnlySQL(readOnly)); err != nil {
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Commit 提交事务，无论成功与否会话都会退出事务
 func (s *Session) Commit() (err error) {
+	if s.tx == nil {
+		return ErrNoTransaction
+	}
+	defer s.endTx()
 	log.Info("Commit transaction")
+	if s.readOnly { // 连接会归还连接池，提交前恢复可写
+		_ = s.setReadOnly(false)
+	}
 	if err = s.tx.Commit(); err != nil {
 		log.Error(err)
 	}
@@ -21,9 +82,57 @@ func (s *Session) Commit() (err error) {
 
 // Rollback 回滚事务(撤诉所有修改、释放锁、连接归还连接池) 不会进行重试
 func (s *Session) Rollback() (err error) {
+	if s.tx == nil {
+		return ErrNoTransaction
+	}
+	defer s.endTx()
 	log.Info("Rollback transaction")
+	if s.readOnly {
+		_ = s.setReadOnly(false)
+	}
 	if err = s.tx.Rollback(); err != nil {
 		log.Error(err)
 	}
 	return
 }
+
+// 保存点名只允许标识符，防止拼接SQL时注入
+var savepointName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Savepoint 在当前事务中创建保存点
+func (s *Session) Savepoint(name string) error {
+	return s.execSavepoint(name, func(d dialect.SavepointDialect) string { return d.SavepointSQL(name) },
+		"SAVEPOINT "+name)
+}
+
+// RollbackTo 回滚到指定保存点，保存点之前的修改保留，事务仍处于开启状态
+func (s *Session) RollbackTo(name string) error {
+	return s.execSavepoint(name, func(d dialect.SavepointDialect) string { return d.RollbackToSQL(name) },
+		"ROLLBACK TO SAVEPOINT "+name)
+}
+
+// ReleaseSavepoint 释放保存点，保存点之后的修改并入外层事务
+func (s *Session) ReleaseSavepoint(name string) error {
+	return s.execSavepoint(name, func(d dialect.SavepointDialect) string { return d.ReleaseSavepointSQL(name) },
+		"RELEASE SAVEPOINT "+name)
+}
+
+// execSavepoint 在事务连接上执行保存点语句，方言实现了 SavepointDialect 时使用方言的语句
+func (s *Session) execSavepoint(name string, dialectSQL func(dialect.SavepointDialect) string, defaultSQL string) error {
+	if s.tx == nil {
+		return errors.New("savepoint requires an active transaction")
+	}
+	if !savepointName.MatchString(name) {
+		return fmt.Errorf("invalid savepoint name %q", name)
+	}
+	sql := defaultSQL
+	if d, ok := s.dialect.(dialect.SavepointDialect); ok {
+		sql = dialectSQL(d)
+	}
+	defer s.logStatement(sql, nil, time.Now()) // 与普通语句一样遵循日志配置
+	if _, err := s.tx.Exec(sql); err != nil {
+		log.Error(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+﻿package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSession_BeginRollback(t *testing.T) {
+	s := testRecordInit(t)
+	if err := s.BeginTx(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Begin(); err == nil {
+		t.Fatal("expect error when beginning a nested transaction")
+	}
+	if _, err := s.Insert(user3); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	// 事务结束后会话重新使用连接池
+	if count, err := s.Where("Name = ?", "Jack").Count(); err != nil || count != 0 {
+		t.Fatal("expect inserted row rolled back, got", count, err)
+	}
+	if err := s.Rollback(); !errors.Is(err, ErrNoTransaction) {
+		t.Fatal("expect ErrNoTransaction, got", err)
+	}
+}
+
+func TestSession_BeginCommit(t *testing.T) {
+	s := testRecordInit(t)
+	if err := s.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Insert(user3); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := s.Count(); err != nil || count != 3 {
+		t.Fatal("expect committed row visible, got", count, err)
+	}
+	if err := s.Commit(); !errors.Is(err, ErrNoTransaction) {
+		t.Fatal("expect ErrNoTransaction, got", err)
+	}
+}
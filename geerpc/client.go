@@ -5,7 +5,6 @@ package geerpc
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -35,6 +34,8 @@ type Call struct {
 	Reply         interface{} // 结果指针（由用户传入）
 	Error         error       // 错误信息
 	Done          chan *Call  // 调用结束通知通道，收到 *Call 即表示完成
+	// Ctx 可选，仅 BatchCall 使用：该调用独立的截止时间，结束时只有该调用以超时错误完成
+	Ctx context.Context
 }
 
 // Client 是一个 RPC 客户端连接实例
@@ -48,8 +49,14 @@ type Client struct {
 	pending  map[uint64]*Call // 记录已发送未完成的请求
 	closing  bool             // 用户主动调用 Close 时为 true
 	shutdown bool             // 服务端/网络错误导致不可用时为 true
+	doneCap  int              // Go 默认创建的 done 通道容量
+	reading  bool             // receive 正在读取某个已移出 pending 的响应体
+	drained  chan struct{}    // CloseGracefully 等待在途调用结束的信号，非 nil 时表示正在等待
 }
 
+// 默认 done 通道容量
+const defaultDoneBufferSize = 10
+
 type clientResult struct {
 	client *Client
 	err    error
@@ -58,15 +65,63 @@ type clientResult struct {
 // done 把 call 发送到 Done 通道，通知调用方请求已完成
 func (c *Call) done() { c.Done <- c }
 
-// Close 优雅关闭客户端连接
+// Close 优雅关闭客户端连接，并以 ErrShutdown 结束所有未完成的调用
+// 可重复、并发调用：只有第一次会真正关闭连接，之后返回 ErrShutdown
 func (c *Client) Close() error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	if c.closing { // 防止重复关闭
+		c.mu.Unlock()
 		return ErrShutdown
 	}
 	c.closing = true
-	return c.cc.Close()
+	c.mu.Unlock()
+	return c.closeConn()
+}
+
+// CloseGracefully 停止接受新的调用，等待在途调用全部完成（或 ctx 结束）后再关闭连接
+// ctx 结束时仍未完成的调用以 ErrShutdown 结束，并返回 ctx.Err()；重复关闭返回 ErrShutdown
+func (c *Client) CloseGracefully(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closing {
+		c.mu.Unlock()
+		return ErrShutdown
+	}
+	c.closing = true // 此后 registerCall 拒绝新的调用
+	drained := make(chan struct{})
+	c.drained = drained
+	c.checkDrained()
+	c.mu.Unlock()
+
+	var waitErr error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		waitErr = ctx.Err()
+	}
+	if err := c.closeConn(); waitErr == nil {
+		return err
+	}
+	return waitErr
+}
+
+// closeConn 关闭底层连接，并以 ErrShutdown 结束所有未完成的调用
+func (c *Client) closeConn() error {
+	c.sending.Lock() // 等待正在进行的写操作结束，避免向已关闭的连接写入
+	defer c.sending.Unlock()
+	err := c.cc.Close()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.finishCalls(ErrShutdown)
+	return err
+}
+
+// checkDrained 在 CloseGracefully 等待期间，没有挂起调用且没有正在读取的响应时发出信号
+// 调用方需持有 c.mu
+func (c *Client) checkDrained() {
+	if c.drained != nil && len(c.pending) == 0 && !c.reading {
+		close(c.drained)
+		c.drained = nil
+	}
 }
 
 // IsAvailable 判断连接是否仍可用
@@ -76,6 +131,13 @@ func (c *Client) IsAvailable() bool {
 	return !c.closing && !c.shutdown
 }
 
+// Pending 返回已发送但尚未完成的调用数量，可用于负载均衡
+func (c *Client) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending)
+}
+
 // registerCall 把新的调用注册到 pending 映射，并分配唯一序号
 func (c *Client) registerCall(call *Call) (uint64, error) {
 	c.mu.Lock()
@@ -95,9 +157,29 @@ func (c *Client) removeCall(seq uint64) *Call {
 	defer c.mu.Unlock()
 	call := c.pending[seq]
 	delete(c.pending, seq)
+	c.checkDrained()
 	return call
 }
 
+// startReading 取出 seq 对应的调用并标记正在读取响应体，
+// 避免 CloseGracefully 在响应体读完之前关闭连接
+func (c *Client) startReading(seq uint64) *Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	call := c.pending[seq]
+	delete(c.pending, seq)
+	c.reading = true
+	return call
+}
+
+// doneReading 响应体读取完毕，清除读取标记
+func (c *Client) doneReading() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reading = false
+	c.checkDrained()
+}
+
 // terminateCalls 在连接异常/关闭时，将所有未完成的调用标记错误并结束
 func (c *Client) terminateCalls(err error) {
 	c.sending.Lock() // 先锁发送再锁状态，确保顺序一致
@@ -105,10 +187,18 @@ func (c *Client) terminateCalls(err error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.shutdown = true
-	for _, call := range c.pending {
+	c.finishCalls(err)
+}
+
+// finishCalls 以 err 结束并移除所有挂起调用，保证每个调用只通知一次
+// 调用方需持有 c.mu
+func (c *Client) finishCalls(err error) {
+	for seq, call := range c.pending {
+		delete(c.pending, seq)
 		call.Error = err
 		call.done()
 	}
+	c.checkDrained()
 }
 
 // receive 在后台 goroutine 中持续读取服务端响应
@@ -120,14 +210,18 @@ func (c *Client) receive() {
 		if err = c.cc.ReadHeader(&h); err != nil {
 			break // 读头失败，跳出循环
 		}
-		call := c.removeCall(h.Seq) // 找到对应调用
+		call := c.startReading(h.Seq) // 找到对应调用
 		switch {
 		case call == nil:
 			// 序号不存在：可能是超时已被移除，丢弃响应体
 			err = c.cc.ReadBody(nil)
-		case h.Error != "":
-			// 服务端返回错误
-			call.Error = fmt.Errorf("%s", h.Error)
+		case h.Error != "" || h.Code != 0:
+			// 服务端返回错误，带错误码时还原为 *RPCError
+			if h.Code != 0 {
+				call.Error = &RPCError{Code: h.Code, Message: h.Error}
+			} else {
+				call.Error = fmt.Errorf("%s", h.Error)
+			}
 			err = c.cc.ReadBody(nil)
 			call.done()
 		default:
@@ -138,6 +232,7 @@ func (c *Client) receive() {
 			}
 			call.done()
 		}
+		c.doneReading()
 	}
 	// 出现任何读取错误，结束所有挂起调用
 	c.terminateCalls(err)
@@ -145,19 +240,18 @@ func (c *Client) receive() {
 
 // NewClient 基于已建立的连接和 Option 创建并初始化客户端
 func NewClient(conn net.Conn, opt *Option) (*Client, error) {
-	f := codec.NewCodecFuncMap[opt.CodecType]
-	if f == nil {
-		err := fmt.Errorf("invalid codec type %s", opt.CodecType)
+	cc, err := newCodec(conn, opt)
+	if err != nil {
 		log.Println("rpc client: codec error:", err)
 		return nil, err
 	}
-	// 先把 Option 以 JSON 形式写给服务端做握手
-	if err := json.NewEncoder(conn).Encode(opt); err != nil {
+	// 先把 Option 写给服务端做握手（默认 JSON，可选二进制）
+	if err := writeOption(conn, opt); err != nil {
 		log.Println("rpc client: options error:", err)
 		_ = conn.Close()
 		return nil, err
 	}
-	return newClientCodec(f(conn), opt), nil
+	return newClientCodec(cc, opt), nil
 }
 
 // newClientCodec 创建客户端实例并启动 receive goroutine
@@ -167,6 +261,10 @@ func newClientCodec(cc codec.Codec, opt *Option) *Client {
 		cc:      cc,
 		opt:     opt,
 		pending: make(map[uint64]*Call),
+		doneCap: opt.DoneBufferSize,
+	}
+	if client.doneCap <= 0 {
+		client.doneCap = defaultDoneBufferSize
 	}
 	go client.receive() // 后台持续读取响应
 	return client
@@ -211,7 +309,11 @@ func parseOptions(opts ...*Option) (*Option, error) {
 func (c *Client) send(call *Call) {
 	c.sending.Lock()
 	defer c.sending.Unlock()
+	c.write(call)
+}
 
+// write 注册并写出单个请求，调用方需持有 c.sending
+func (c *Client) write(call *Call) {
 	// 注册到 pending，获取序号
 	seq, err := c.registerCall(call)
 	if err != nil {
@@ -238,7 +340,7 @@ func (c *Client) send(call *Call) {
 // 如果 done == nil，自动创建带缓冲通道；否则要求通道必须有缓冲
 func (c *Client) Go(serviceMethod string, args, reply interface{}, done chan *Call) *Call {
 	if done == nil {
-		done = make(chan *Call, 10) // 默认缓冲 10，避免调用方阻塞
+		done = make(chan *Call, c.doneCap) // 默认带缓冲，避免调用方阻塞
 	} else if cap(done) == 0 {
 		log.Panic("rpc client: done channel is unbuffered")
 	}
@@ -268,6 +370,69 @@ func (c *Client) Call(ctx context.Context, serviceMethod string, args, reply int
 	}
 }
 
+// CallTimeout 同步调用的便捷封装：在 timeout 内未完成则返回超时错误
+// 内部创建 context.WithTimeout 并调用 Call，调用结束后释放
+func (c *Client) CallTimeout(timeout time.Duration, serviceMethod string, args, reply interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return c.Call(ctx, serviceMethod, args, reply)
+}
+
+// BatchCall 批量同步调用：只获取一次发送锁，连续写出所有请求后等待全部完成，
+// 返回按 calls 顺序的第一个错误。每个 Call 需设置 ServiceMethod、Args、Reply，
+// Done 为 nil 时自动创建；ctx 结束时未完成的调用被移除并返回超时错误。
+// 设置了 Call.Ctx 的调用各自独立超时：其 Ctx 结束时仅该调用被移除并以超时错误完成，
+// 其余调用照常等待结果，各调用的结果见 Call.Error
+func (c *Client) BatchCall(ctx context.Context, calls []*Call) error {
+	for _, call := range calls {
+		if call.Done == nil {
+			call.Done = make(chan *Call, 1)
+		} else if cap(call.Done) == 0 {
+			log.Panic("rpc client: done channel is unbuffered")
+		}
+	}
+	c.sending.Lock()
+	for _, call := range calls {
+		if call.Ctx != nil && call.Ctx.Err() != nil { // 发送前已超时，不再发送
+			call.Error = fmt.Errorf("rpc client: call timeout: %w", call.Ctx.Err())
+			call.done()
+			continue
+		}
+		c.write(call)
+	}
+	c.sending.Unlock()
+
+	for _, call := range calls {
+		if call.Ctx == nil {
+			continue
+		}
+		stop := context.AfterFunc(call.Ctx, func() {
+			// 只有仍在 pending 中的调用才由这里结束，已收到响应的调用不受影响
+			if c.removeCall(call.Seq) != nil {
+				call.Error = fmt.Errorf("rpc client: call timeout: %w", call.Ctx.Err())
+				call.done()
+			}
+		})
+		defer stop()
+	}
+
+	var err error
+	for i, call := range calls {
+		select {
+		case <-ctx.Done(): // 超时或取消，移除剩余的调用
+			for _, rest := range calls[i:] {
+				c.removeCall(rest.Seq)
+			}
+			return fmt.Errorf("rpc client: call timeout: %w", ctx.Err())
+		case done := <-call.Done:
+			if done.Error != nil && err == nil {
+				err = done.Error
+			}
+		}
+	}
+	return err
+}
+
 func dialTimeout(f newClientFunc, network, address string, opts ...*Option) (client *Client, err error) {
 	opt, err := parseOptions(opts...)
 	if err != nil {
@@ -331,15 +496,36 @@ func DialHTTP(network, address string, opts ...*Option) (*Client, error) {
 }
 
 func XDial(rpcAddr string, opts ...*Option) (*Client, error) {
-	parts := strings.Split(rpcAddr, "@")
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("rpc client err: wrong format '%s', expect protocol@addr", rpcAddr)
+	protocol, addr, err := parseRPCAddr(rpcAddr)
+	if err != nil {
+		return nil, err
 	}
-	protocol, addr := parts[0], parts[1]
 	switch protocol {
 	case "http":
 		return DialHTTP("tcp", addr, opts...)
+	case "unix":
+		return Dial("unix", addr, opts...) // addr 为 socket 文件路径
 	default:
 		return Dial(protocol, addr, opts...)
 	}
 }
+
+// xdialProtocols XDial 支持的协议
+var xdialProtocols = map[string]bool{"tcp": true, "tcp4": true, "tcp6": true, "unix": true, "http": true}
+
+// parseRPCAddr 将 protocol@addr 拆分为协议与地址，只按第一个 @ 拆分，
+// 因此地址(如 unix socket 路径)中可以包含 @；IPv6 地址需写成 [::1]:port 形式
+func parseRPCAddr(rpcAddr string) (protocol, addr string, err error) {
+	i := strings.Index(rpcAddr, "@")
+	if i < 0 {
+		return "", "", fmt.Errorf("rpc client err: wrong format '%s', expect protocol@addr", rpcAddr)
+	}
+	protocol, addr = rpcAddr[:i], rpcAddr[i+1:]
+	if !xdialProtocols[protocol] {
+		return "", "", fmt.Errorf("rpc client err: unknown protocol '%s' in '%s'", protocol, rpcAddr)
+	}
+	if addr == "" {
+		return "", "", fmt.Errorf("rpc client err: empty address in '%s'", rpcAddr)
+	}
+	return protocol, addr, nil
+}
@@ -2,10 +2,14 @@
 
 import (
 	"context"
+	"errors"
 	"net"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -35,6 +39,15 @@ func (b Bar) Timeout(argv int, reply *int) error {
 	return nil
 }
 
+func (b Bar) Double(argv int, reply *int) error {
+	*reply = argv * 2
+	return nil
+}
+
+func (b Bar) Panic(argv int, reply *int) error {
+	panic("boom")
+}
+
 func startServer(addr chan string) {
 	var b Bar
 	_ = Register(&b)
@@ -57,6 +70,22 @@ func TestClient_Call(t *testing.T) {
 		cancel()
 		_assert(err != nil && strings.Contains(err.Error(), ctx.Err().Error()), "expect a timeout error")
 	})
+	t.Run("call timeout helper", func(t *testing.T) { // CallTimeout 便捷封装
+		client, _ := Dial("tcp", addr)
+		var reply int
+		err := client.CallTimeout(100*time.Millisecond, "Bar.Timeout", 1, &reply)
+		_assert(errors.Is(err, context.DeadlineExceeded), "expect a timeout error")
+		err = client.CallTimeout(time.Second, "Bar.Double", 21, &reply)
+		_assert(err == nil && reply == 42, "expect a successful call")
+	})
+	t.Run("server panic", func(t *testing.T) { // 服务端方法 panic
+		client, _ := Dial("tcp", addr)
+		var reply int
+		for i := 0; i < 2; i++ { // 第二次调用仍能得到响应，说明服务端未崩溃
+			err := client.Call(context.Background(), "Bar.Panic", 1, &reply)
+			_assert(err != nil && strings.Contains(err.Error(), "Bar.Panic panic: boom"), "expect a panic error")
+		}
+	})
 	t.Run("server handle timeout", func(t *testing.T) { // 服务端处理超时
 		client, _ := Dial("tcp", addr, &Option{
 			HandleTimeout: time.Second,
@@ -67,6 +96,168 @@ func TestClient_Call(t *testing.T) {
 	})
 }
 
+func TestClient_Close(t *testing.T) {
+	t.Parallel()
+	addrCh := make(chan string)
+	go startServer(addrCh)
+	addr := <-addrCh
+	client, err := Dial("tcp", addr)
+	_assert(err == nil, "failed to dial: %v", err)
+	calls := make([]*Call, 5)
+	for i := range calls { // 发起若干耗时调用，关闭时它们仍在途
+		var reply int
+		calls[i] = client.Go("Bar.Timeout", i, &reply, make(chan *Call, 1))
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ { // 并发关闭两次
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- client.Close()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	var closed int
+	for err := range errs {
+		if err == nil {
+			closed++
+		} else {
+			_assert(errors.Is(err, ErrShutdown), "expect ErrShutdown, got %v", err)
+		}
+	}
+	_assert(closed == 1, "expect exactly one effective close, got %d", closed)
+	for _, call := range calls {
+		select {
+		case call = <-call.Done:
+			_assert(errors.Is(call.Error, ErrShutdown), "expect ErrShutdown, got %v", call.Error)
+		case <-time.After(time.Second):
+			t.Fatal("pending call is not finished after close")
+		}
+	}
+	_assert(!client.IsAvailable(), "client should be unavailable after close")
+}
+
+func TestClient_BatchCall(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	var foo Foo
+	_ = server.Register(&foo)
+	l, _ := net.Listen("tcp", ":0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+
+	client, err := Dial("tcp", l.Addr().String())
+	_assert(err == nil, "failed to dial: %v", err)
+	defer func() { _ = client.Close() }()
+	calls := make([]*Call, 100)
+	replies := make([]int, len(calls))
+	for i := range calls {
+		calls[i] = &Call{ServiceMethod: "Foo.Sum", Args: Args{Num1: i, Num2: i * i}, Reply: &replies[i]}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	err = client.BatchCall(ctx, calls)
+	_assert(err == nil, "failed to batch call: %v", err)
+	for i, reply := range replies {
+		_assert(reply == i+i*i, "wrong reply for call %d: %d", i, reply)
+	}
+
+	err = client.BatchCall(ctx, []*Call{
+		{ServiceMethod: "Foo.Sum", Args: Args{Num1: 1, Num2: 2}, Reply: new(int)},
+		{ServiceMethod: "Foo.Unknown", Args: Args{}, Reply: new(int)},
+	})
+	_assert(err != nil && strings.Contains(err.Error(), "can't find method"), "expect the first error, got %v", err)
+}
+
+// Store 用于测试带错误码的错误
+type Store int
+
+func (s Store) Echo(key string, reply *string) error {
+	*reply = key
+	return nil
+}
+
+func (s Store) Get(key string, reply *string) error {
+	if key == "plain" {
+		return errors.New("plain error")
+	}
+	return &RPCError{Code: 404, Message: "key " + key + " not found"}
+}
+
+func TestClient_BatchCallPerCallContext(t *testing.T) {
+	server := NewServer()
+	_ = server.Register(new(Foo))
+	_ = server.Register(new(Slow))
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+
+	client, err := Dial("tcp", l.Addr().String())
+	_assert(err == nil, "failed to dial: %v", err)
+	defer func() { _ = client.Close() }()
+
+	short, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	var sum1, slow, sum2 int
+	calls := []*Call{
+		{ServiceMethod: "Foo.Sum", Args: Args{Num1: 1, Num2: 2}, Reply: &sum1},
+		{ServiceMethod: "Slow.Wait", Args: 7, Reply: &slow, Ctx: short}, // 耗时 50ms，超过自身截止时间
+		{ServiceMethod: "Foo.Sum", Args: Args{Num1: 3, Num2: 4}, Reply: &sum2},
+	}
+	err = client.BatchCall(context.Background(), calls)
+	_assert(errors.Is(err, context.DeadlineExceeded), "expect the timed-out call's error, got %v", err)
+	_assert(calls[0].Error == nil && sum1 == 3, "expect first call to succeed, got %v %d", calls[0].Error, sum1)
+	_assert(errors.Is(calls[1].Error, context.DeadlineExceeded), "expect slow call to time out, got %v", calls[1].Error)
+	_assert(calls[2].Error == nil && sum2 == 7, "expect last call to succeed, got %v %d", calls[2].Error, sum2)
+	_assert(client.Pending() == 0, "expect no pending calls left, got %d", client.Pending())
+
+	// 超时调用的迟到响应被丢弃，连接仍可继续使用
+	time.Sleep(time.Millisecond * 60)
+	_assert(client.Call(context.Background(), "Foo.Sum", Args{Num1: 5, Num2: 6}, &sum1) == nil && sum1 == 11,
+		"expect connection still usable after a per-call timeout")
+}
+
+func TestClient_RPCError(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	var store Store
+	_ = server.Register(&store)
+	l, _ := net.Listen("tcp", ":0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+
+	client, err := Dial("tcp", l.Addr().String())
+	_assert(err == nil, "failed to dial: %v", err)
+	defer func() { _ = client.Close() }()
+	var reply string
+	err = client.Call(context.Background(), "Store.Get", "Tom", &reply)
+	var rpcErr *RPCError
+	_assert(errors.As(err, &rpcErr) && rpcErr.Code == 404 && rpcErr.Message == "key Tom not found", "expect RPCError 404, got %v", err)
+
+	err = client.Call(context.Background(), "Store.Get", "plain", &reply)
+	_assert(err != nil && !errors.As(err, &rpcErr) && err.Error() == "plain error", "expect plain error, got %v", err)
+}
+
+func TestClient_CompressMinBytes(t *testing.T) {
+	server := NewServer()
+	var s Store
+	_ = server.Register(&s)
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+
+	client, err := Dial("tcp", l.Addr().String(), &Option{CompressMinBytes: 64})
+	_assert(err == nil, "dial error: %v", err)
+	defer func() { _ = client.Close() }()
+	for _, key := range []string{"k", strings.Repeat("k", 1000)} { // 小消息不压缩，大消息压缩
+		var reply string
+		err := client.Call(context.Background(), "Store.Echo", key, &reply)
+		_assert(err == nil && reply == key, "expect echoed key of %d bytes, got %d bytes %v", len(key), len(reply), err)
+	}
+}
+
 func TestXDial(t *testing.T) {
 	if runtime.GOOS == "linux" { // 只在linux下测试unix socket
 		ch := make(chan struct{}) // 阻塞主goroutine，等待子goroutine运行结束
@@ -85,3 +276,109 @@ func TestXDial(t *testing.T) {
 		_assert(err == nil, "failed to connect unix socket")
 	}
 }
+
+func TestXDial_Protocols(t *testing.T) {
+	dial := func(t *testing.T, rpcAddr string) {
+		t.Helper()
+		client, err := XDial(rpcAddr)
+		if err != nil {
+			t.Fatalf("failed to dial %s: %v", rpcAddr, err)
+		}
+		_ = client.Close()
+	}
+	for _, network := range []string{"tcp", "tcp4"} {
+		addr, closer, err := ListenAndServe(network, "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		dial(t, network+"@"+addr)
+		closer()
+	}
+	if addr, closer, err := ListenAndServe("tcp6", "[::1]:0"); err == nil { // 环境不支持 IPv6 时跳过
+		dial(t, "tcp6@"+addr)
+		closer()
+	}
+	if runtime.GOOS == "linux" {
+		addr, closer, err := ListenAndServe("unix", filepath.Join(t.TempDir(), "gee@rpc.sock")) // 路径中包含 @
+		if err != nil {
+			t.Fatal(err)
+		}
+		dial(t, "unix@"+addr)
+		closer()
+	}
+	ts := httptest.NewServer(NewServer())
+	defer ts.Close()
+	dial(t, "http@"+ts.Listener.Addr().String())
+
+	for rpcAddr, msg := range map[string]string{
+		"127.0.0.1:9999":     "wrong format",
+		"udp@127.0.0.1:9999": "unknown protocol",
+		"tcp@":               "empty address",
+	} {
+		_, err := XDial(rpcAddr)
+		_assert(err != nil && strings.Contains(err.Error(), msg), "expect %q error for %s, got %v", msg, rpcAddr, err)
+	}
+}
+
+func TestClient_CloseGracefully(t *testing.T) {
+	addr, closer, err := ListenAndServe("tcp", "127.0.0.1:0", new(Slow))
+	_assert(err == nil, "failed to listen: %v", err)
+	defer closer()
+
+	t.Run("drain", func(t *testing.T) {
+		client, err := Dial("tcp", addr)
+		_assert(err == nil, "failed to dial: %v", err)
+		var reply int
+		call := client.Go("Slow.Wait", 7, &reply, nil)
+		closed := make(chan error, 1)
+		go func() { closed <- client.CloseGracefully(context.Background()) }()
+		for client.IsAvailable() { // 等待进入关闭状态
+			time.Sleep(time.Millisecond)
+		}
+		err = client.Call(context.Background(), "Slow.Wait", 1, new(int))
+		_assert(errors.Is(err, ErrShutdown), "expect new call rejected with ErrShutdown, got %v", err)
+		select {
+		case call = <-call.Done:
+			_assert(call.Error == nil && reply == 7, "expect in-flight call to complete, got %v %d", call.Error, reply)
+		case <-time.After(time.Second):
+			t.Fatal("in-flight call is not finished")
+		}
+		_assert(<-closed == nil, "expect graceful close without error")
+		_assert(errors.Is(client.Close(), ErrShutdown), "expect repeated close to return ErrShutdown")
+	})
+	t.Run("ctx expired", func(t *testing.T) {
+		client, err := Dial("tcp", addr)
+		_assert(err == nil, "failed to dial: %v", err)
+		call := client.Go("Slow.Wait", 7, new(int), nil)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+		defer cancel()
+		err = client.CloseGracefully(ctx)
+		_assert(errors.Is(err, context.DeadlineExceeded), "expect DeadlineExceeded, got %v", err)
+		call = <-call.Done
+		_assert(errors.Is(call.Error, ErrShutdown), "expect unfinished call ended with ErrShutdown, got %v", call.Error)
+	})
+}
+
+func TestClient_DoneBufferSize(t *testing.T) {
+	addr, closer, err := ListenAndServe("tcp", "127.0.0.1:0", new(Foo))
+	_assert(err == nil, "failed to listen: %v", err)
+	defer closer()
+
+	client, err := Dial("tcp", addr)
+	_assert(err == nil, "failed to dial: %v", err)
+	call := client.Go("Foo.Sum", &Args{1, 2}, new(int), nil)
+	_assert(cap(call.Done) == defaultDoneBufferSize, "expect default capacity %d, got %d", defaultDoneBufferSize, cap(call.Done))
+	<-call.Done
+	_ = client.Close()
+
+	client, err = Dial("tcp", addr, &Option{DoneBufferSize: 64})
+	_assert(err == nil, "failed to dial: %v", err)
+	defer func() { _ = client.Close() }()
+	call = client.Go("Foo.Sum", &Args{1, 2}, new(int), nil)
+	_assert(cap(call.Done) == 64, "expect configured capacity 64, got %d", cap(call.Done))
+	<-call.Done
+	_assert(call.Error == nil && *call.Reply.(*int) == 3, "unexpected call result: %v", call.Error)
+	// 调用方传入的无缓冲通道仍然 panic
+	defer func() { _assert(recover() != nil, "expect panic for unbuffered done channel") }()
+	client.Go("Foo.Sum", &Args{1, 2}, new(int), make(chan *Call))
+}
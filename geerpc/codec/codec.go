@@ -26,6 +26,7 @@ type Header struct {
 	ServiceMethod string // 服务方法名，格式为 "Service.Method"，用于定位服务端对应的方法
 	Seq           uint64 // 客户端请求的唯一序列号，用于匹配响应
 	Error         string // 服务端返回的错误信息（如果有）
+	Code          int    // 服务端返回的错误码，0 表示普通错误
 }
 
 // Codec 是一个接口，定义了所有编解码器必须实现的方法。
@@ -0,0 +1,57 @@
+﻿package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"io"
+)
+
+// 消息体帧的首字节标志，表示其后的数据是否经过 gzip 压缩
+const (
+	frameRaw  byte = 0 // 未压缩
+	frameGzip byte = 1 // gzip 压缩
+)
+
+// encodeBodyFrame 把 body 独立 gob 编码为一帧：首字节为压缩标志，其后为数据。
+// 编码后大小超过 minBytes 时才压缩，避免小消息承担压缩开销。
+func encodeBodyFrame(body interface{}, minBytes int) ([]byte, error) {
+	var raw bytes.Buffer
+	if err := gob.NewEncoder(&raw).Encode(body); err != nil {
+		return nil, err
+	}
+	if raw.Len() <= minBytes {
+		return append([]byte{frameRaw}, raw.Bytes()...), nil
+	}
+	var frame bytes.Buffer
+	frame.WriteByte(frameGzip)
+	zw := gzip.NewWriter(&frame)
+	if _, err := zw.Write(raw.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return frame.Bytes(), nil
+}
+
+// decodeBodyFrame 按首字节标志解压（如有必要）并把数据解码到 body。
+// body 为 nil 时只校验帧，丢弃数据。
+func decodeBodyFrame(frame []byte, body interface{}) error {
+	if len(frame) == 0 {
+		return io.ErrUnexpectedEOF
+	}
+	var r io.Reader = bytes.NewReader(frame[1:])
+	if frame[0] == frameGzip {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = zr.Close() }()
+		r = zr
+	}
+	if body == nil {
+		return nil
+	}
+	return gob.NewDecoder(r).Decode(body)
+}
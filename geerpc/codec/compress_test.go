@@ -0,0 +1,50 @@
+﻿package codec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// bufConn 用内存缓冲区模拟连接，写入的数据可被同一缓冲区读出
+type bufConn struct {
+	bytes.Buffer
+}
+
+func (c *bufConn) Close() error { return nil }
+
+func TestEncodeBodyFrame(t *testing.T) {
+	small, err := encodeBodyFrame("hi", 64)
+	if err != nil || small[0] != frameRaw {
+		t.Fatalf("small body should be sent raw, got flag %d, err %v", small[0], err)
+	}
+	large := strings.Repeat("geerpc", 100)
+	frame, err := encodeBodyFrame(large, 64)
+	if err != nil || frame[0] != frameGzip {
+		t.Fatalf("large body should be compressed, got flag %d, err %v", frame[0], err)
+	}
+	if len(frame) >= len(large) {
+		t.Fatalf("compressed frame should be smaller, got %d bytes", len(frame))
+	}
+}
+
+func TestGobCodecCompress(t *testing.T) {
+	conn := &bufConn{}
+	w, r := NewGobCodecCompress(conn, 64), NewGobCodecCompress(conn, 64)
+	bodies := []string{"hi", strings.Repeat("geerpc", 100)}
+	for i, body := range bodies {
+		if err := w.Write(&Header{ServiceMethod: "Foo.Sum", Seq: uint64(i)}, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, body := range bodies {
+		var h Header
+		var got string
+		if err := r.ReadHeader(&h); err != nil || h.Seq != uint64(i) {
+			t.Fatalf("read header error: %v, seq %d", err, h.Seq)
+		}
+		if err := r.ReadBody(&got); err != nil || got != body {
+			t.Fatalf("body %d mismatch, err %v", i, err)
+		}
+	}
+}
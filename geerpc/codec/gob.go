@@ -16,6 +16,8 @@ type GobCodec struct {
 	buf  *bufio.Writer      // 带缓冲的写入器，减少系统调用次数
 	dec  *gob.Decoder       // gob 解码器，从 conn 读取数据
 	enc  *gob.Encoder       // gob 编码器，向 buf 写入数据
+	// compressMin > 0 时消息体以独立帧传输，编码后超过该字节数才 gzip 压缩
+	compressMin int
 }
 
 // NewGobCodec 构造并返回一个新的 GobCodec 实例
@@ -30,6 +32,15 @@ func NewGobCodec(conn io.ReadWriteCloser) Codec {
 	}
 }
 
+// NewGobCodecCompress 构造按大小决定是否压缩消息体的 GobCodec。
+// 消息体独立编码为帧，首字节标志是否压缩，编码后超过 minBytes 字节时才压缩；
+// 通信双方必须都使用该构造函数。minBytes <= 0 时等同于 NewGobCodec
+func NewGobCodecCompress(conn io.ReadWriteCloser, minBytes int) Codec {
+	c := NewGobCodec(conn).(*GobCodec)
+	c.compressMin = minBytes
+	return c
+}
+
 // ReadHeader 从连接中读取并解码消息头
 // 参数 h 是指向 Header 的指针，用于存储读取到的数据
 func (c *GobCodec) ReadHeader(h *Header) error {
@@ -39,6 +50,13 @@ func (c *GobCodec) ReadHeader(h *Header) error {
 // ReadBody 从连接中读取并解码消息体
 // 参数 body 是一个 interface{}，用于接收解码后的数据
 func (c *GobCodec) ReadBody(body interface{}) error {
+	if c.compressMin > 0 {
+		var frame []byte
+		if err := c.dec.Decode(&frame); err != nil {
+			return err
+		}
+		return decodeBodyFrame(frame, body)
+	}
 	return c.dec.Decode(body)
 }
 
@@ -50,7 +68,7 @@ func (c *GobCodec) Write(h *Header, body interface{}) (err error) {
 		_ = c.buf.Flush()
 		// 如果写入过程中发生错误，则关闭连接
 		if err != nil {
-			log.Fatal("rpc codec: gob error writing:", err)
+			log.Println("rpc codec: gob error writing:", err)
 			_ = c.conn.Close()
 		}
 	}()
@@ -61,6 +79,19 @@ func (c *GobCodec) Write(h *Header, body interface{}) (err error) {
 		return err
 	}
 
+	// 按需压缩后以独立帧写入消息体
+	if c.compressMin > 0 {
+		frame, err := encodeBodyFrame(body, c.compressMin)
+		if err == nil {
+			err = c.enc.Encode(frame)
+		}
+		if err != nil {
+			log.Println("rpc codec: gob error encoding body frame:", err)
+			return err
+		}
+		return nil
+	}
+
 	// 编码并写入消息体
 	if err := c.enc.Encode(body); err != nil {
 		log.Println("rpc codec: gob error encoding body:", err)
@@ -1,9 +1,12 @@
 ﻿package geerpc
 
 import (
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"net/http"
+	"sort"
+	"strings"
 )
 
 const debugText = `<html>
@@ -15,10 +18,10 @@ const debugText = `<html>
 	<hr>
 		<table>
 		<th align=center>Method</th><th align=center>Calls</th>
-		{{range $name, $mtype := .Method}}
+		{{range .Method}}
 			<tr>
-			<td align=left font=fixed>{{$name}}({{$mtype.ArgType}}, {{$mtype.ReplyType}}) error</td>
-			<td align=center>{{$mtype.NumCalls}}</td>
+			<td align=left font=fixed>{{.Name}}({{.Type.ArgType}}, {{.Type.ReplyType}}) error</td>
+			<td align=center>{{.Type.NumCalls}}</td>
 			</tr>
 		{{end}}
 		</table>
@@ -35,22 +38,84 @@ type debugHTTP struct {
 
 type debugService struct {
 	Name   string
-	Method map[string]*methodType
+	Method []debugMethod // 按调用次数降序排列
 }
 
+type debugMethod struct {
+	Name string
+	Type *methodType
+}
+
+// 调试信息的 JSON 表示，便于监控工具抓取
+type debugServiceJSON struct {
+	Name    string            `json:"name"`
+	Methods []debugMethodJSON `json:"methods"`
+}
+
+type debugMethodJSON struct {
+	Name      string `json:"name"`
+	ArgType   string `json:"argType"`
+	ReplyType string `json:"replyType"`
+	NumCalls  uint64 `json:"numCalls"`
+}
+
+// ServeHTTP 默认渲染 HTML 页面；带 ?format=json 或 Accept: application/json 时返回 JSON
 func (s debugHTTP) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	services := s.services()
+	if req.URL.Query().Get("format") == "json" || strings.Contains(req.Header.Get("Accept"), "application/json") {
+		data := make([]debugServiceJSON, 0, len(services))
+		for _, svc := range services {
+			methods := make([]debugMethodJSON, 0, len(svc.Method))
+			for _, m := range svc.Method {
+				methods = append(methods, debugMethodJSON{
+					Name:      m.Name,
+					ArgType:   m.Type.ArgType.String(),
+					ReplyType: m.Type.ReplyType.String(),
+					NumCalls:  m.Type.NumCalls(),
+				})
+			}
+			data = append(data, debugServiceJSON{Name: svc.Name, Methods: methods})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			http.Error(w, "rpc: error encoding json: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	err := debug.Execute(w, services) // 渲染模板，并写入响应
+	if err != nil {
+		_, _ = fmt.Fprintln(w, "rpc: error executing template:", err.Error())
+	}
+}
+
+// services 收集未隐藏的服务及其方法信息，服务按名称排序
+func (s debugHTTP) services() []debugService {
 	var services []debugService
 	// 遍历服务列表
 	s.serviceMap.Range(func(namei, svci interface{}) bool {
-		svc := svci.(*service)                    // 获取服务实例指针
+		name := namei.(string)
+		if _, hidden := s.hidden.Load(name); hidden { // 跳过被隐藏的服务
+			return true
+		}
+		svc := svci.(*service) // 获取服务实例指针
+		methods := make([]debugMethod, 0, len(svc.method))
+		for mname, mtype := range svc.method {
+			methods = append(methods, debugMethod{Name: mname, Type: mtype})
+		}
+		// 调用次数多的排在前面，次数相同按方法名排序
+		sort.Slice(methods, func(i, j int) bool {
+			ci, cj := methods[i].Type.NumCalls(), methods[j].Type.NumCalls()
+			if ci != cj {
+				return ci > cj
+			}
+			return methods[i].Name < methods[j].Name
+		})
 		services = append(services, debugService{ // 构造服务信息
-			Name:   namei.(string),
-			Method: svc.method,
+			Name:   name,
+			Method: methods,
 		})
 		return true
 	})
-	err := debug.Execute(w, services) // 渲染模板，并写入响应
-	if err != nil {
-		_, _ = fmt.Fprintln(w, "rpc: error executing template:", err.Error())
-	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services
 }
@@ -0,0 +1,45 @@
+﻿package geerpc
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDebugHTTP(t *testing.T) {
+	server := NewServer()
+	var foo Foo
+	var b Bar
+	_ = server.Register(&foo)
+	_ = server.Register(&b)
+	server.HideFromDebug("Foo")
+	_, mtype, _ := server.findService("Bar.Timeout")
+	mtype.numCalls = 2 // Timeout 调用次数更多，应排在 Panic 之前
+
+	w := httptest.NewRecorder()
+	debugHTTP{server}.ServeHTTP(w, httptest.NewRequest("GET", defaultDebugPath, nil))
+	body := w.Body.String()
+	_assert(!strings.Contains(body, "Service Foo"), "hidden service should be absent")
+	_assert(strings.Contains(body, "Service Bar"), "visible service should be present")
+	timeout, panicIdx := strings.Index(body, "Timeout("), strings.Index(body, "Panic(")
+	_assert(timeout >= 0 && panicIdx >= 0 && timeout < panicIdx, "methods should be sorted by calls")
+}
+
+func TestDebugHTTP_JSON(t *testing.T) {
+	server := NewServer()
+	var foo Foo
+	_ = server.Register(&foo)
+
+	w := httptest.NewRecorder()
+	debugHTTP{server}.ServeHTTP(w, httptest.NewRequest("GET", defaultDebugPath+"?format=json", nil))
+	var services []debugServiceJSON
+	err := json.Unmarshal(w.Body.Bytes(), &services)
+	expect := []debugServiceJSON{{
+		Name:    "Foo",
+		Methods: []debugMethodJSON{{Name: "Sum", ArgType: "geerpc.Args", ReplyType: "*int", NumCalls: 0}},
+	}}
+	_assert(err == nil && reflect.DeepEqual(services, expect), "unexpected json debug output: %s", w.Body.String())
+	_assert(w.Header().Get("Content-Type") == "application/json", "expect json content type")
+}
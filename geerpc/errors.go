@@ -0,0 +1,16 @@
+﻿package geerpc
+
+import "fmt"
+
+// RPCError 是带错误码的 RPC 错误。
+// 服务方法返回 *RPCError 时，错误码与信息会一并写入响应头，
+// 客户端据此重建 *RPCError，调用方可通过 errors.As 取出错误码。
+// 普通 error 的错误码视为 0，客户端仍得到普通错误。
+type RPCError struct {
+	Code    int    // 错误码，由业务自行约定，0 保留给普通错误
+	Message string // 错误信息
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error: code = %d, message = %s", e.Code, e.Message)
+}
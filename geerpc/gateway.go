@@ -0,0 +1,73 @@
+﻿package geerpc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// jsonGateway 把 HTTP+JSON 请求转换为对已注册服务的直接调用
+type jsonGateway struct {
+	*Server
+}
+
+// gatewayError JSON 网关的错误响应
+type gatewayError struct {
+	Error string `json:"error"`
+	Code  int    `json:"code,omitempty"` // 服务方法返回 *RPCError 时的错误码
+}
+
+// NewJSONGateway 返回一个 JSON-over-HTTP 网关，便于浏览器、curl 等调用 RPC 服务。
+// 请求格式为 POST /rpc/{Service}/{Method}，请求体为参数的 JSON，
+// 成功时返回 reply 的 JSON，失败时返回 {"error": ..., "code": ...}。
+func NewJSONGateway(server *Server) http.Handler {
+	return jsonGateway{server}
+}
+
+func (g jsonGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeGatewayJSON(w, http.StatusMethodNotAllowed, gatewayError{Error: "rpc gateway: must POST"})
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, defaultGatewayPath), "/")
+	if !strings.HasPrefix(r.URL.Path, defaultGatewayPath) || len(parts) != 2 {
+		writeGatewayJSON(w, http.StatusNotFound, gatewayError{Error: "rpc gateway: path must be " + defaultGatewayPath + "{Service}/{Method}"})
+		return
+	}
+	svc, mtype, err := g.findService(parts[0] + "." + parts[1])
+	if err != nil {
+		writeGatewayJSON(w, http.StatusNotFound, gatewayError{Error: err.Error()})
+		return
+	}
+
+	// 与 readRequest 相同：创建参数与返回值实例，非指针参数取地址后再反序列化
+	argv, replyv := mtype.newArgv(), mtype.newReplyv()
+	argvi := argv.Interface()
+	if argv.Type().Kind() != reflect.Ptr {
+		argvi = argv.Addr().Interface()
+	}
+	if err := json.NewDecoder(r.Body).Decode(argvi); err != nil {
+		writeGatewayJSON(w, http.StatusBadRequest, gatewayError{Error: "rpc gateway: invalid json: " + err.Error()})
+		return
+	}
+
+	if err := svc.call(mtype, argv, replyv); err != nil {
+		resp := gatewayError{Error: err.Error()}
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) {
+			resp.Error, resp.Code = rpcErr.Message, rpcErr.Code
+		}
+		writeGatewayJSON(w, http.StatusInternalServerError, resp)
+		return
+	}
+	writeGatewayJSON(w, http.StatusOK, replyv.Interface())
+}
+
+// writeGatewayJSON 以 JSON 写出响应
+func writeGatewayJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,39 @@
+﻿package geerpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestJSONGateway(t *testing.T) {
+	server := NewServer()
+	var foo Foo
+	_ = server.Register(&foo)
+	gateway := NewJSONGateway(server)
+
+	t.Run("call", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, httptest.NewRequest("POST", "/rpc/Foo/Sum", strings.NewReader(`{"Num1": 1, "Num2": 2}`)))
+		var reply int
+		err := json.Unmarshal(w.Body.Bytes(), &reply)
+		_assert(w.Code == http.StatusOK && err == nil && reply == 3, "expect 3, got %d %s", w.Code, w.Body.String())
+	})
+	t.Run("unknown method", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, httptest.NewRequest("POST", "/rpc/Foo/Missing", strings.NewReader(`{}`)))
+		_assert(w.Code == http.StatusNotFound, "expect 404, got %d", w.Code)
+	})
+	t.Run("bad json", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, httptest.NewRequest("POST", "/rpc/Foo/Sum", strings.NewReader(`{`)))
+		_assert(w.Code == http.StatusBadRequest, "expect 400, got %d", w.Code)
+	})
+	t.Run("not post", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, httptest.NewRequest("GET", "/rpc/Foo/Sum", nil))
+		_assert(w.Code == http.StatusMethodNotAllowed, "expect 405, got %d", w.Code)
+	})
+}
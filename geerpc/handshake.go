@@ -0,0 +1,83 @@
+﻿package geerpc
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/nukecoke1828/7daysProgram/geerpc/codec"
+)
+
+// 二进制握手的定长前缀：魔数(4 字节) + 处理超时(8 字节，纳秒) + 编解码类型长度(1 字节)，
+// 之后紧跟编解码类型字符串。魔数大端序首字节为 0x00，可与 JSON 的 '{' 区分。
+const binaryHandshakeLen = 4 + 8 + 1
+
+// handshakeConn 优先从 Reader 读取数据，写入与关闭仍交给原连接
+type handshakeConn struct {
+	io.Reader
+	io.ReadWriteCloser
+}
+
+func (c *handshakeConn) Read(p []byte) (int, error) {
+	return c.Reader.Read(p)
+}
+
+// writeOption 把 Option 发送给服务端，默认使用 JSON，开启 BinaryHandshake 时使用定长二进制前缀
+func writeOption(w io.Writer, opt *Option) error {
+	if !opt.BinaryHandshake {
+		return json.NewEncoder(w).Encode(opt)
+	}
+	if opt.CompressMinBytes > 0 {
+		return errors.New("rpc client: binary handshake does not carry CompressMinBytes")
+	}
+	if len(opt.CodecType) > 0xff {
+		return errors.New("rpc client: codec type is too long")
+	}
+	buf := make([]byte, binaryHandshakeLen+len(opt.CodecType))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(opt.MagicNumber))
+	binary.BigEndian.PutUint64(buf[4:12], uint64(opt.HandleTimeout))
+	buf[12] = byte(len(opt.CodecType))
+	copy(buf[binaryHandshakeLen:], opt.CodecType)
+	_, err := w.Write(buf) // 一次写出，避免握手被拆成多个包
+	return err
+}
+
+// readOption 从连接读取客户端的 Option，根据首字节自动识别 JSON 或二进制握手。
+// 返回的 Reader 包含握手之后已被预读的数据，后续请求应从它读取。
+func readOption(conn io.Reader) (*Option, io.Reader, error) {
+	r := bufio.NewReader(conn)
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, nil, err
+	}
+	var opt Option
+	if first[0] != '{' {
+		buf := make([]byte, binaryHandshakeLen)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, nil, err
+		}
+		codecType := make([]byte, buf[12])
+		if _, err := io.ReadFull(r, codecType); err != nil {
+			return nil, nil, err
+		}
+		opt.MagicNumber = int(binary.BigEndian.Uint32(buf[0:4]))
+		opt.HandleTimeout = time.Duration(binary.BigEndian.Uint64(buf[4:12]))
+		opt.CodecType = codec.Type(codecType)
+		opt.BinaryHandshake = true
+		return &opt, r, nil
+	}
+	dec := json.NewDecoder(r)
+	if err := dec.Decode(&opt); err != nil {
+		return nil, nil, err
+	}
+	// JSON 解码器可能已多读了紧随 Option 之后的请求数据，需先交还给编解码器；
+	// 客户端 json.Encoder 会在 Option 后追加换行符，跳过它
+	br := bufio.NewReader(io.MultiReader(dec.Buffered(), r))
+	if b, err := br.Peek(1); err == nil && b[0] == '\n' {
+		_, _ = br.Discard(1)
+	}
+	return &opt, br, nil
+}
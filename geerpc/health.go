@@ -0,0 +1,22 @@
+﻿package geerpc
+
+import "context"
+
+// Health 是内置的健康检查服务，每个 Server 都能应答 Health.Ping，无需手动注册。
+// 内置服务不出现在 Services 与调试页面中；用户注册了同名服务时以用户的为准。
+type Health struct{}
+
+// Ping 原样返回参数，用于探测连接与服务端处理循环是否存活
+func (Health) Ping(args int, reply *int) error {
+	*reply = args
+	return nil
+}
+
+// healthService 是内置 Health 服务的实例，所有 Server 共用
+var healthService = newService(&Health{})
+
+// Ping 调用服务端的 Health.Ping，检查连接是否仍然可用
+func (c *Client) Ping(ctx context.Context) error {
+	var reply int
+	return c.Call(ctx, "Health.Ping", 1, &reply)
+}
@@ -0,0 +1,60 @@
+﻿package geerpc
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Invoker 执行一次服务方法调用，args 与 reply 分别是请求参数与响应值
+// 拦截器可以改写三者再交给 next：最终调用使用传入的方法与参数，并把传入的 reply 作为响应发送，
+// args 与 reply 的类型须与方法的参数类型一致
+type Invoker func(serviceMethod string, args, reply interface{}) error
+
+// Interceptor 是服务端拦截器，包装 Invoker 以在调用前后加入日志、鉴权、统计等逻辑
+type Interceptor func(next Invoker) Invoker
+
+// Logger 是 AccessLog 使用的日志接口，*log.Logger 即满足该接口
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Use 注册服务端拦截器，按注册顺序由外到内执行，需在开始服务前调用
+func (s *Server) Use(interceptors ...Interceptor) {
+	s.interceptors = append(s.interceptors, interceptors...)
+}
+
+// invoke 经过拦截器链调用服务方法
+func (s *Server) invoke(req *request) error {
+	invoker := func(serviceMethod string, args, reply interface{}) error {
+		svc, mtype := req.svc, req.mtype
+		if serviceMethod != req.h.ServiceMethod { // 拦截器改写了调用的方法
+			var err error
+			if svc, mtype, err = s.findService(serviceMethod); err != nil {
+				return err
+			}
+		}
+		argv, replyv := reflect.ValueOf(args), reflect.ValueOf(reply)
+		if !argv.IsValid() || argv.Type() != mtype.ArgType || !replyv.IsValid() || replyv.Type() != mtype.ReplyType {
+			return fmt.Errorf("rpc server: %s expects (%s, %s), got (%T, %T)", serviceMethod, mtype.ArgType, mtype.ReplyType, args, reply)
+		}
+		req.replyv = replyv // 响应发送传入的 reply
+		return svc.call(mtype, argv, replyv)
+	}
+	for i := len(s.interceptors) - 1; i >= 0; i-- {
+		invoker = s.interceptors[i](invoker)
+	}
+	return invoker(req.h.ServiceMethod, req.argv.Interface(), req.replyv.Interface())
+}
+
+// AccessLog 返回记录每次调用的服务方法、耗时与错误的拦截器，通过 Server.Use 启用
+func AccessLog(logger Logger) Interceptor {
+	return func(next Invoker) Invoker {
+		return func(serviceMethod string, args, reply interface{}) error {
+			start := time.Now()
+			err := next(serviceMethod, args, reply)
+			logger.Printf("rpc server: %s duration=%s error=%v", serviceMethod, time.Since(start), err)
+			return err
+		}
+	}
+}
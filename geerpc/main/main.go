@@ -98,16 +98,16 @@ func call(addrCh chan string) {
 // startServer2 启动一个随机端口的 RPC Server，并向注册中心定时心跳
 func startServer2(registryAddr string, wg *sync.WaitGroup) {
 	var foo Foo
-	// 监听随机端口
-	l, _ := net.Listen("tcp", ":0")
-	server := geerpc.NewServer()
-	_ = server.Register(&foo)
+	// 监听随机端口并在后台提供服务
+	addr, _, err := geerpc.ListenAndServe("tcp", ":0", &foo)
+	if err != nil {
+		log.Fatal("listen error:", err)
+	}
 
 	// 向注册中心发送心跳（第二个参数为服务地址，格式如 "tcp@127.0.0.1:12345"）
-	registry.Heartbeat(registryAddr, "tcp@"+l.Addr().String(), 0)
+	registry.Heartbeat(registryAddr, "tcp@"+addr, 0)
 
 	wg.Done() // 通知 main 本服务已就绪
-	server.Accept(l)
 }
 
 // Sleep 是 Foo 的另一个 RPC 方法，用于广播演示
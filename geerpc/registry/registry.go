@@ -45,12 +45,56 @@ func (r *GeeRegistry) putServer(addr string) {
 	}
 }
 
+// expired 判断节点是否超时，调用方需持有 r.mu
+func (r *GeeRegistry) expired(s *ServerItem, now time.Time) bool {
+	return r.timeout != 0 && !s.start.Add(r.timeout).After(now) // 超时时间为0时永久存活
+}
+
+// sweep 删除所有超时节点
+func (r *GeeRegistry) sweep() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for addr, s := range r.servers {
+		if r.expired(s, now) {
+			delete(r.servers, addr)
+		}
+	}
+}
+
+// StartSweeper 启动后台 goroutine，每隔 interval 清理一次超时节点，
+// 使没有 GET 请求时过期节点也能被及时删除；返回的 stop 用于停止清理
+func (r *GeeRegistry) StartSweeper(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				r.sweep()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+func (r *GeeRegistry) removeServer(addr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.servers, addr)
+}
+
 func (r *GeeRegistry) aliveServers() []string {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	var alive []string
+	now := time.Now()
 	for addr, s := range r.servers {
-		if r.timeout == 0 || s.start.Add(r.timeout).After(time.Now()) { // 如果超时时间为0，则永久存活；否则，计算存活时间，并判断是否存活
+		if !r.expired(s, now) { // 未超时则存活
 			alive = append(alive, addr)
 		} else { // 超时，则删除
 			delete(r.servers, addr)
@@ -71,6 +115,13 @@ func (r *GeeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 		r.putServer(addr)
+	case "DELETE": // 注销节点
+		addr := req.Header.Get("X-Geerpc-Server")
+		if addr == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		r.removeServer(addr)
 	default: // 其他方法不允许
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
@@ -85,19 +136,37 @@ func HandleHTTP() {
 	DefaultGeeRegistry.HandleHTTP(defaultPath)
 }
 
-func Heartbeat(registry, addr string, duration time.Duration) {
+// Heartbeat 向注册中心注册 addr 并定时发送心跳，返回的 stop 停止心跳并从注册中心注销该节点
+// stop 可重复调用，返回时心跳 goroutine 已退出
+func Heartbeat(registry, addr string, duration time.Duration) (stop func()) {
 	if duration == 0 { // 如果超时时间为0，则使用默认超时时间
 		duration = defaultTimeout - time.Duration(1)*time.Minute
 	}
 	var err error
 	err = sendHeartbeat(registry, addr) // 第一次发送心跳用于注册
-	go func() {                         // 定时发送心跳
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() { // 定时发送心跳
+		defer close(exited)
 		t := time.NewTicker(duration) // 时间间隔为duration
+		defer t.Stop()
 		for err == nil {
-			<-t.C // 阻塞goroutine，等待duration时间
-			err = sendHeartbeat(registry, addr)
+			select {
+			case <-t.C: // 等待duration时间
+				err = sendHeartbeat(registry, addr)
+			case <-done:
+				return
+			}
 		}
 	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-exited
+			_ = deregister(registry, addr)
+		})
+	}
 }
 
 func sendHeartbeat(registry, addr string) error {
@@ -111,3 +180,15 @@ func sendHeartbeat(registry, addr string) error {
 	}
 	return nil
 }
+
+func deregister(registry, addr string) error {
+	req, _ := http.NewRequest("DELETE", registry, nil)
+	req.Header.Set("X-Geerpc-Server", addr)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Println("rpc server: deregister error:", err)
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,80 @@
+﻿package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingRegistry struct {
+	mu      sync.Mutex
+	posts   int
+	deletes int
+}
+
+func (r *recordingRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch req.Method {
+	case "POST":
+		r.posts++
+	case "DELETE":
+		r.deletes++
+	}
+}
+
+func (r *recordingRegistry) counts() (int, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.posts, r.deletes
+}
+
+func TestHeartbeat_Stop(t *testing.T) {
+	rec := &recordingRegistry{}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+
+	stop := Heartbeat(ts.URL, "tcp@127.0.0.1:9999", 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	stop()
+	stop() // 重复调用无副作用
+	posts, deletes := rec.counts()
+	if posts < 2 || deletes != 1 {
+		t.Fatalf("expect heartbeats and one deregister, got posts=%d deletes=%d", posts, deletes)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if after, _ := rec.counts(); after != posts {
+		t.Fatalf("expect no heartbeat after stop, got %d more", after-posts)
+	}
+}
+
+func TestGeeRegistry_Deregister(t *testing.T) {
+	r := New(defaultTimeout)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	stop := Heartbeat(ts.URL, "tcp@127.0.0.1:9999", time.Minute)
+	if alive := r.aliveServers(); len(alive) != 1 {
+		t.Fatal("expect server registered, got", alive)
+	}
+	stop()
+	if alive := r.aliveServers(); len(alive) != 0 {
+		t.Fatal("expect server deregistered, got", alive)
+	}
+}
+
+func TestGeeRegistry_StartSweeper(t *testing.T) {
+	r := New(20 * time.Millisecond)
+	r.putServer("tcp@127.0.0.1:9999")
+	stop := r.StartSweeper(10 * time.Millisecond)
+	defer stop()
+	time.Sleep(80 * time.Millisecond)
+	r.mu.Lock()
+	n := len(r.servers)
+	r.mu.Unlock()
+	if n != 0 {
+		t.Fatal("expect expired server swept without GET, got", n)
+	}
+}
@@ -3,7 +3,6 @@
 package geerpc
 
 import (
-	"encoding/json" // JSON 编解码
 	"errors"
 	"fmt"
 	"io"
@@ -11,6 +10,7 @@ import (
 	"net"
 	"net/http"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -24,9 +24,10 @@ import (
 const MagicNumber = 0x3bef5c
 
 const (
-	connected        = "200 Connected to Gee RPC"
-	defaultRPCPath   = "/_geeprc_"
-	defaultDebugPath = "/debug/geerpc"
+	connected          = "200 Connected to Gee RPC"
+	defaultRPCPath     = "/_geeprc_"
+	defaultDebugPath   = "/debug/geerpc"
+	defaultGatewayPath = "/rpc/" // JSON 网关的路径前缀
 )
 
 // DefaultOption 是默认的协议选项实例。
@@ -45,17 +46,43 @@ var invalidRequest = struct{}{}
 
 // Option 定义了建立连接时需要协商的协议选项。
 type Option struct {
-	MagicNumber    int        // 魔数，用于协议识别
-	CodecType      codec.Type // 选定的编解码类型（如 Gob、JSON）
-	ConnectTimeout time.Duration
-	HandleTimeout  time.Duration
+	MagicNumber    int           // 魔数，用于协议识别
+	CodecType      codec.Type    // 选定的编解码类型（如 Gob、JSON）
+	ConnectTimeout time.Duration // 客户端建立连接的超时时间，0 表示不限制
+	// HandleTimeout 客户端期望的服务端处理超时，0 表示不额外要求。
+	// 服务端的超时由 Server.HandleTimeout 与方法级超时决定，该值只能把超时缩短，不能放宽或取消
+	HandleTimeout time.Duration
+	// BinaryHandshake 为 true 时以定长二进制前缀代替 JSON 发送 Option，
+	// 仅携带魔数、HandleTimeout 与 CodecType，适合可信内网的高吞吐场景
+	BinaryHandshake bool
+	// CompressMinBytes > 0 时消息体编码后超过该字节数才 gzip 压缩，仅支持 Gob 编码，
+	// 需通过 JSON 握手告知服务端
+	CompressMinBytes int
+	// DoneBufferSize 为 Client.Go 传入 nil done 时默认创建的通道容量，<=0 时为 10；
+	// 仅客户端使用，不发送给服务端
+	DoneBufferSize int `json:"-"`
 }
 
 // Server 表示一个 RPC 服务端实例。
 type Server struct {
-	serviceMap sync.Map // 线程安全地保存所有注册的服务，key=服务名，value=*service
+	serviceMap       sync.Map      // 线程安全地保存所有注册的服务，key=服务名，value=*service
+	HandshakeTimeout time.Duration // 读取 Option 的超时时间，0 表示不限制
+	// HandleTimeout 处理单个请求的超时时间，0 表示沿用 DefaultOption.HandleTimeout（默认不限制）
+	HandleTimeout time.Duration
+	hidden        sync.Map // 不在调试页面展示的服务名集合，key=服务名
+	// MaxWorkers 限制单个连接上同时处理的请求数，0 表示不限制。
+	// 达到上限时暂停读取新请求，形成背压
+	MaxWorkers   int
+	interceptors []Interceptor       // 服务端拦截器，由 Use 注册
+	codecs       map[codec.Type]bool // 允许协商的编解码类型，为 nil 时不限制，由 AllowCodecs 设置
 }
 
+// Accept 遇到临时错误时退避等待的上限
+const maxAcceptDelay = time.Second
+
+// 默认的握手超时时间：5 秒，防止连接后迟迟不发送 Option 的客户端长期占用 goroutine
+const defaultHandshakeTimeout = time.Second * 5
+
 // request 封装一次 RPC 请求的所有信息。
 type request struct {
 	h            *codec.Header // 请求头
@@ -66,17 +93,46 @@ type request struct {
 
 // NewServer 返回一个新的 Server 实例。
 func NewServer() *Server {
-	return &Server{}
+	return &Server{HandshakeTimeout: defaultHandshakeTimeout}
+}
+
+// AllowCodecs 限制客户端握手时可选用的编解码类型，其他类型的连接会被拒绝。
+// 不传参数时取消限制；需在开始服务前调用
+func (s *Server) AllowCodecs(types ...codec.Type) {
+	if len(types) == 0 {
+		s.codecs = nil
+		return
+	}
+	s.codecs = make(map[codec.Type]bool, len(types))
+	for _, t := range types {
+		s.codecs[t] = true
+	}
 }
 
 // Accept 监听并接收来自 Listener 的连接，每收到一个连接就启动一个 goroutine 处理。
 func (s *Server) Accept(lis net.Listener) {
+	var tempDelay time.Duration // 临时错误时的退避时间
 	for {
 		conn, err := lis.Accept() // 阻塞等待客户端连接
 		if err != nil {
+			// 临时错误（如文件描述符耗尽）按指数退避重试，参照 net/http 的做法
+			if te, ok := err.(interface{ Temporary() bool }); ok && te.Temporary() {
+				if tempDelay == 0 {
+					tempDelay = 5 * time.Millisecond
+				} else {
+					tempDelay *= 2
+				}
+				if tempDelay > maxAcceptDelay {
+					tempDelay = maxAcceptDelay
+				}
+				log.Printf("rpc server: accept error: %v; retrying in %v", err, tempDelay)
+				time.Sleep(tempDelay)
+				continue
+			}
 			log.Println("rpc server: accept error:", err)
 			return
 		}
+		tempDelay = 0
 		// 每个连接独立处理，互不阻塞
 		go s.ServeConn(conn)
 	}
@@ -87,8 +143,26 @@ func Accept(lis net.Listener) {
 	DefaultServer.Accept(lis)
 }
 
+// ListenAndServe 新建 Server 并注册 services，在 network/addr 上监听并在后台开始服务。
+// addr 可以使用 ":0" 由系统分配端口，返回实际监听的地址；
+// closer 用于关闭监听器，停止接收新连接（已建立的连接不受影响）。
+func ListenAndServe(network, addr string, services ...interface{}) (actualAddr string, closer func(), err error) {
+	server := NewServer()
+	for _, svc := range services {
+		if err = server.Register(svc); err != nil {
+			return "", nil, err
+		}
+	}
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		return "", nil, err
+	}
+	go server.Accept(l)
+	return l.Addr().String(), func() { _ = l.Close() }, nil
+}
+
 // ServeConn 处理单个客户端连接。
-// 1. 使用 JSON 解码 Option（握手阶段）
+// 1. 解码 Option（握手阶段，JSON 或定长二进制）
 // 2. 验证魔数
 // 3. 根据 Option.CodecType 创建对应编解码器
 // 4. 进入请求处理循环
@@ -96,31 +170,79 @@ func (s *Server) ServeConn(conn io.ReadWriteCloser) {
 	defer func() { _ = conn.Close() }()
 
 	// 第一步：读取并解码客户端发送的 Option
-	var opt Option
-	if err := json.NewDecoder(conn).Decode(&opt); err != nil {
+	// 握手阶段设置读超时，连接支持 SetReadDeadline（如 net.Conn）时生效
+	d, ok := conn.(interface{ SetReadDeadline(time.Time) error })
+	if ok && s.HandshakeTimeout > 0 {
+		_ = d.SetReadDeadline(time.Now().Add(s.HandshakeTimeout))
+	}
+	opt, r, err := readOption(conn)
+	if err != nil {
 		log.Println("rpc server: options error:", err)
 		return
 	}
+	if ok && s.HandshakeTimeout > 0 {
+		_ = d.SetReadDeadline(time.Time{}) // 握手完成，清除超时
+	}
 	// 第二步：验证魔数是否正确
 	if opt.MagicNumber != MagicNumber {
 		log.Println("rpc server: magic number error:", opt.MagicNumber)
 		return
 	}
-	// 第三步：根据 CodecType 获取编解码器构造函数
-	f := codec.NewCodecFuncMap[opt.CodecType]
-	if f == nil {
-		log.Printf("rpc server: invalid codec type %s", opt.CodecType)
+	// 第三步：根据 CodecType 创建编解码器，握手时预读的数据需交还给编解码器
+	cc, err := newCodec(&handshakeConn{Reader: r, ReadWriteCloser: conn}, opt)
+	if err != nil {
+		log.Println("rpc server:", err)
+		return
+	}
+	if s.codecs != nil && !s.codecs[opt.CodecType] { // 不允许的编解码类型，每个请求都以错误响应
+		err = fmt.Errorf("rpc server: codec type %s is not allowed by this server", opt.CodecType)
+		log.Println(err)
+		s.rejectCodec(cc, err)
 		return
 	}
 	// 第四步：使用创建的编解码器进入请求处理循环
-	s.serveCodec(f(conn))
+	s.serveCodec(cc, opt)
+}
+
+// rejectCodec 对连接上的每个请求都回复 err，直到连接关闭，客户端的调用因此得到明确的错误
+func (s *Server) rejectCodec(cc codec.Codec, err error) {
+	defer func() { _ = cc.Close() }()
+	for {
+		var h codec.Header
+		if cc.ReadHeader(&h) != nil || cc.ReadBody(nil) != nil { // 丢弃请求体
+			return
+		}
+		h.Error = err.Error()
+		if cc.Write(&h, invalidRequest) != nil {
+			return
+		}
+	}
+}
+
+// newCodec 根据 Option 创建编解码器，客户端与服务端共用
+func newCodec(conn io.ReadWriteCloser, opt *Option) (codec.Codec, error) {
+	if opt.CompressMinBytes > 0 {
+		if opt.CodecType != codec.GobType {
+			return nil, fmt.Errorf("compression is not supported by codec type %s", opt.CodecType)
+		}
+		return codec.NewGobCodecCompress(conn, opt.CompressMinBytes), nil
+	}
+	f := codec.NewCodecFuncMap[opt.CodecType]
+	if f == nil {
+		return nil, fmt.Errorf("invalid codec type %s", opt.CodecType)
+	}
+	return f(conn), nil
 }
 
 // serveCodec 使用给定的编解码器循环读取请求、处理并发送响应。
 // 使用 sync.WaitGroup 等待所有并发请求完成后再关闭连接。
-func (s *Server) serveCodec(cc codec.Codec) {
+func (s *Server) serveCodec(cc codec.Codec, opt *Option) {
 	sending := new(sync.Mutex) // 保证并发写响应时的顺序安全
 	wg := new(sync.WaitGroup)  // 等待所有请求处理完成
+	var workers chan struct{}  // 空闲名额，容量即并发上限
+	if s.MaxWorkers > 0 {
+		workers = make(chan struct{}, s.MaxWorkers)
+	}
 
 	for {
 		// 读取一个完整请求
@@ -137,7 +259,13 @@ func (s *Server) serveCodec(cc codec.Codec) {
 		}
 		// 并发处理请求
 		wg.Add(1)
-		go s.handleRequest(cc, req, sending, wg, DefaultOption.HandleTimeout)
+		if workers == nil {
+			go s.handleRequest(cc, req, sending, wg, opt.HandleTimeout, nil)
+			continue
+		}
+		workers <- struct{}{} // 名额用尽时阻塞，不再读取新请求
+		// 名额在业务方法真正返回时才归还：超时返回响应后方法可能仍在执行
+		go s.handleRequest(cc, req, sending, wg, opt.HandleTimeout, func() { <-workers })
 	}
 	// 等待所有 goroutine 完成后关闭连接，防止未完成就被关闭
 	wg.Wait()
@@ -192,17 +320,44 @@ func (s *Server) sendResponse(cc codec.Codec, h *codec.Header, body interface{},
 	}
 }
 
-// handleRequest 处理单个请求并发送响应。
-func (s *Server) handleRequest(cc codec.Codec, req *request, sending *sync.Mutex, wg *sync.WaitGroup, timeout time.Duration) {
+// handleTimeout 返回请求的处理超时：由服务端决定，方法注册时设置了超时则使用方法级超时，
+// 否则使用 Server.HandleTimeout；客户端握手时要求的 requested 更短时采用 requested，
+// 客户端只能缩短超时，无法放宽或取消服务端的限制
+func (s *Server) handleTimeout(mtype *methodType, requested time.Duration) time.Duration {
+	timeout := s.HandleTimeout
+	if timeout == 0 {
+		timeout = DefaultOption.HandleTimeout
+	}
+	if mtype.timeout > 0 {
+		timeout = mtype.timeout
+	}
+	if requested > 0 && (timeout == 0 || requested < timeout) {
+		timeout = requested
+	}
+	return timeout
+}
+
+// handleRequest 处理单个请求并发送响应，requested 为客户端握手时要求的超时；
+// release 不为 nil 时在业务方法返回后调用，即使请求已经超时
+func (s *Server) handleRequest(cc codec.Codec, req *request, sending *sync.Mutex, wg *sync.WaitGroup, requested time.Duration, release func()) {
 	defer wg.Done()
+	timeout := s.handleTimeout(req.mtype, requested)
 	// 通道使用struct类型0内存占用，同时防止误用
-	called := make(chan struct{}) // 业务方法执行完成的信号
-	sent := make(chan struct{})   // 响应数据已写入连接的信号
+	// 带缓冲，超时返回后业务 goroutine 仍能发送信号并退出，不会泄漏
+	called := make(chan struct{}, 1) // 业务方法执行完成的信号
+	sent := make(chan struct{}, 1)   // 响应数据已写入连接的信号
 	go func() {
-		err := req.svc.call(req.mtype, req.argv, req.replyv)
+		err := s.invoke(req) // 经过拦截器链调用服务方法
+		if release != nil {
+			release()
+		}
 		called <- struct{}{} // 通知调用完成
 		if err != nil {      // 调用失败，发送错误响应
 			req.h.Error = err.Error()
+			var rpcErr *RPCError
+			if errors.As(err, &rpcErr) { // 带错误码的错误，码与信息分开传输
+				req.h.Code, req.h.Error = rpcErr.Code, rpcErr.Message
+			}
 			s.sendResponse(cc, req.h, invalidRequest, sending)
 			sent <- struct{}{} // 通知响应已发送
 			return
@@ -234,11 +389,74 @@ func (s *Server) Register(rcvr interface{}) error {
 	return nil
 }
 
+// RegisterName 以指定的服务名注册 rcvr，调用时作用于该实例本身，
+// 因此同一类型的多个有状态实例（如持有不同数据库连接）可以用不同的名字分别注册
+func (s *Server) RegisterName(name string, rcvr interface{}) error {
+	if name == "" || strings.Contains(name, ".") {
+		return fmt.Errorf("rpc: invalid service name %q", name)
+	}
+	svc, err := newNamedService(rcvr, name)
+	if err != nil {
+		return err
+	}
+	if _, dup := s.serviceMap.LoadOrStore(name, svc); dup {
+		return errors.New("rpc: service already defined: " + name)
+	}
+	return nil
+}
+
+// RegisterWithTimeouts 注册 rcvr，并为指定方法设置处理超时（键为方法名，如 "Sleep"）
+// 方法级超时优先于 Server.HandleTimeout，未列出的方法仍使用服务端的默认值；
+// 客户端握手时要求更短的超时时仍以客户端为准
+func (s *Server) RegisterWithTimeouts(rcvr interface{}, timeouts map[string]time.Duration) error {
+	svc := newService(rcvr)
+	for name, timeout := range timeouts {
+		mtype, ok := svc.method[name]
+		if !ok {
+			return fmt.Errorf("rpc: can't find method %s.%s", svc.name, name)
+		}
+		if timeout < 0 {
+			return fmt.Errorf("rpc: invalid timeout %s for %s.%s", timeout, svc.name, name)
+		}
+		mtype.timeout = timeout
+	}
+	if _, dup := s.serviceMap.LoadOrStore(svc.name, svc); dup {
+		return errors.New("rpc: service already defined: " + svc.name)
+	}
+	return nil
+}
+
+// Services 返回已注册的服务名及其方法名列表（按名称排序），便于生成接口目录
+func (s *Server) Services() map[string][]string {
+	services := make(map[string][]string)
+	s.serviceMap.Range(func(namei, svci interface{}) bool {
+		svc := svci.(*service)
+		methods := make([]string, 0, len(svc.method))
+		for name := range svc.method {
+			methods = append(methods, name)
+		}
+		sort.Strings(methods)
+		services[namei.(string)] = methods
+		return true
+	})
+	return services
+}
+
+// HideFromDebug 让指定服务不在调试页面中展示（如内部使用的服务），不影响正常调用
+func (s *Server) HideFromDebug(serviceName string) {
+	s.hidden.Store(serviceName, struct{}{})
+}
+
 // Register 使用 DefaultServer 注册服务，简化调用。
 func Register(rcvr interface{}) error {
 	return DefaultServer.Register(rcvr)
 }
 
+// RegisterName 是 DefaultServer 的便捷方法
+func RegisterName(name string, rcvr interface{}) error {
+	return DefaultServer.RegisterName(name, rcvr)
+}
+
 // findService 根据 "Service.Method" 格式字符串查找对应服务和方法。
 func (s *Server) findService(serviceMethod string) (svc *service, mtype *methodType, err error) {
 	dot := strings.LastIndex(serviceMethod, ".")
@@ -247,7 +465,10 @@ func (s *Server) findService(serviceMethod string) (svc *service, mtype *methodT
 		return
 	}
 	serviceName, methodName := serviceMethod[:dot], serviceMethod[dot+1:]
-	svci, ok := s.serviceMap.Load(serviceName) // 从 serviceMap 中查找服务
+	svci, ok := s.serviceMap.Load(serviceName)    // 从 serviceMap 中查找服务
+	if !ok && serviceName == healthService.name { // 未被用户覆盖的内置健康检查服务
+		svci, ok = healthService, true
+	}
 	if !ok {
 		err = errors.New("rpc: can't find service " + serviceName)
 		return
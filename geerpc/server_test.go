@@ -0,0 +1,494 @@
+﻿package geerpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nukecoke1828/7daysProgram/geerpc/codec"
+)
+
+func TestServer_HandshakeTimeout(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	server.HandshakeTimeout = time.Millisecond * 100
+	l, _ := net.Listen("tcp", ":0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	_assert(err == nil, "failed to dial: %v", err)
+	defer func() { _ = conn.Close() }()
+	// 不发送 Option，服务端应在超时后关闭连接
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second * 2))
+	start := time.Now()
+	_, err = conn.Read(make([]byte, 1))
+	_assert(err == io.EOF, "expect server to close the connection, got %v", err)
+	_assert(time.Since(start) < time.Second, "connection closed too late")
+}
+
+func TestServer_BinaryHandshake(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	var foo Foo
+	_ = server.Register(&foo)
+	l, _ := net.Listen("tcp", ":0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+
+	for _, binaryHandshake := range []bool{true, false} {
+		client, err := Dial("tcp", l.Addr().String(), &Option{BinaryHandshake: binaryHandshake})
+		_assert(err == nil, "failed to dial: %v", err)
+		var reply int
+		err = client.Call(context.Background(), "Foo.Sum", Args{Num1: 1, Num2: 2}, &reply)
+		_assert(err == nil && reply == 3, "failed to call with binary handshake %v: %v", binaryHandshake, err)
+		_ = client.Close()
+	}
+}
+
+func BenchmarkHandshake(b *testing.B) {
+	for _, binaryHandshake := range []bool{false, true} {
+		name := "json"
+		if binaryHandshake {
+			name = "binary"
+		}
+		b.Run(name, func(b *testing.B) {
+			opt := &Option{MagicNumber: MagicNumber, CodecType: DefaultOption.CodecType, BinaryHandshake: binaryHandshake}
+			var buf bytes.Buffer
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				_ = writeOption(&buf, opt)
+				if _, _, err := readOption(&buf); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// Slow 记录同时执行的调用数的峰值
+type Slow struct {
+	running, peak int32
+}
+
+func (s *Slow) Wait(args int, reply *int) error {
+	n := atomic.AddInt32(&s.running, 1)
+	for {
+		peak := atomic.LoadInt32(&s.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&s.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond * 50)
+	atomic.AddInt32(&s.running, -1)
+	*reply = args
+	return nil
+}
+
+func TestServer_MaxWorkers(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	server.MaxWorkers = 2
+	slow := &Slow{}
+	_ = server.Register(slow)
+	l, _ := net.Listen("tcp", ":0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+
+	client, err := Dial("tcp", l.Addr().String())
+	_assert(err == nil, "failed to dial: %v", err)
+	defer func() { _ = client.Close() }()
+	calls := make([]*Call, 10)
+	for i := range calls {
+		calls[i] = client.Go("Slow.Wait", i, new(int), make(chan *Call, 1))
+	}
+	for i, call := range calls {
+		call = <-call.Done
+		_assert(call.Error == nil && *call.Reply.(*int) == i, "call %d failed: %v", i, call.Error)
+	}
+	peak := atomic.LoadInt32(&slow.peak)
+	_assert(peak >= 1 && peak <= 2, "expect at most 2 concurrent calls, got %d", peak)
+}
+
+func TestServer_MaxWorkersWithTimeout(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	server.MaxWorkers = 2
+	server.HandleTimeout = time.Millisecond * 10 // 远小于 Slow.Wait 的耗时
+	slow := &Slow{}
+	_ = server.Register(slow)
+	l, _ := net.Listen("tcp", ":0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+
+	client, err := Dial("tcp", l.Addr().String())
+	_assert(err == nil, "failed to dial: %v", err)
+	defer func() { _ = client.Close() }()
+	calls := make([]*Call, 6)
+	for i := range calls {
+		calls[i] = client.Go("Slow.Wait", i, new(int), make(chan *Call, 1))
+	}
+	for i, call := range calls {
+		call = <-call.Done
+		_assert(call.Error != nil && strings.Contains(call.Error.Error(), "handle timeout"), "call %d: expect timeout, got %v", i, call.Error)
+	}
+	// 超时的方法仍在执行时不归还名额
+	peak := atomic.LoadInt32(&slow.peak)
+	_assert(peak >= 1 && peak <= 2, "expect at most 2 concurrent calls after timeouts, got %d", peak)
+}
+
+// tempError 模拟临时性的 Accept 错误
+type tempError struct{}
+
+func (tempError) Error() string   { return "too many open files" }
+func (tempError) Temporary() bool { return true }
+func (tempError) Timeout() bool   { return false }
+
+// flakyListener 先返回若干次临时错误，再返回一个连接，之后返回永久错误
+type flakyListener struct {
+	net.Listener
+	temps   int
+	conn    net.Conn
+	accepts int
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	l.accepts++
+	switch {
+	case l.accepts <= l.temps:
+		return nil, tempError{}
+	case l.conn != nil:
+		conn := l.conn
+		l.conn = nil
+		return conn, nil
+	default:
+		return nil, errors.New("listener closed")
+	}
+}
+
+func TestServer_AcceptTemporaryError(t *testing.T) {
+	t.Parallel()
+	serverConn, clientConn := net.Pipe()
+	l := &flakyListener{temps: 3, conn: serverConn}
+	server := NewServer()
+	var foo Foo
+	_ = server.Register(&foo)
+	done := make(chan struct{})
+	go func() {
+		server.Accept(l)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 2):
+		t.Fatal("Accept should return on a permanent error")
+	}
+	_assert(l.accepts == 5, "expect Accept to retry temporary errors, got %d calls", l.accepts)
+
+	// 临时错误之后接收到的连接应被正常服务
+	client, err := NewClient(clientConn, DefaultOption)
+	_assert(err == nil, "failed to create client: %v", err)
+	defer func() { _ = client.Close() }()
+	var reply int
+	err = client.Call(context.Background(), "Foo.Sum", Args{Num1: 1, Num2: 2}, &reply)
+	_assert(err == nil && reply == 3, "accepted connection should be served: %v", err)
+}
+
+func TestListenAndServe(t *testing.T) {
+	var foo Foo
+	addr, closer, err := ListenAndServe("tcp", "127.0.0.1:0", &foo)
+	_assert(err == nil && !strings.HasSuffix(addr, ":0"), "expect a resolved address, got %s %v", addr, err)
+
+	client, err := Dial("tcp", addr)
+	_assert(err == nil, "dial error: %v", err)
+	defer func() { _ = client.Close() }()
+	var reply int
+	err = client.Call(context.Background(), "Foo.Sum", Args{Num1: 1, Num2: 2}, &reply)
+	_assert(err == nil && reply == 3, "expect 3, got %d %v", reply, err)
+
+	closer()
+	_, err = Dial("tcp", addr)
+	_assert(err != nil, "expect dial error after close")
+
+	_, _, err = ListenAndServe("tcp", "127.0.0.1:0", &foo, &foo) // 重复注册同名服务
+	_assert(err != nil && strings.Contains(err.Error(), "already defined"), "expect a register error, got %v", err)
+}
+
+// captureLogger 记录每一行日志
+type captureLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *captureLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestServer_AccessLog(t *testing.T) {
+	server := NewServer()
+	var foo Foo
+	_ = server.Register(&foo)
+	logger := &captureLogger{}
+	server.Use(AccessLog(logger))
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+
+	client, err := Dial("tcp", l.Addr().String())
+	_assert(err == nil, "dial error: %v", err)
+	defer func() { _ = client.Close() }()
+	for i := 0; i < 2; i++ {
+		var reply int
+		_ = client.Call(context.Background(), "Foo.Sum", Args{Num1: i, Num2: 1}, &reply)
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	_assert(len(logger.lines) == 2, "expect a log line per call, got %v", logger.lines)
+	for _, line := range logger.lines {
+		var method, duration string
+		_, err := fmt.Sscanf(line, "rpc server: %s duration=%s", &method, &duration)
+		d, perr := time.ParseDuration(duration)
+		_assert(err == nil && perr == nil && method == "Foo.Sum" && d >= 0, "unexpected log line: %s", line)
+	}
+}
+
+func TestServer_InterceptorRewrites(t *testing.T) {
+	server := NewServer()
+	var foo Foo
+	_ = server.Register(&foo)
+	server.Use(func(next Invoker) Invoker { // 改写参数
+		return func(serviceMethod string, args, reply interface{}) error {
+			a := args.(Args)
+			a.Num2 *= 10
+			return next(serviceMethod, a, reply)
+		}
+	}, func(next Invoker) Invoker { // 替换响应值
+		return func(serviceMethod string, args, reply interface{}) error {
+			r := new(int)
+			err := next(serviceMethod, args, r)
+			*r += 100
+			return err
+		}
+	})
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+
+	client, err := Dial("tcp", l.Addr().String())
+	_assert(err == nil, "dial error: %v", err)
+	defer func() { _ = client.Close() }()
+	var reply int
+	err = client.Call(context.Background(), "Foo.Sum", Args{Num1: 1, Num2: 2}, &reply)
+	_assert(err == nil && reply == 121, "expect rewritten args and reply to take effect, got %d %v", reply, err)
+}
+
+func TestServer_InterceptorTypeMismatch(t *testing.T) {
+	server := NewServer()
+	var foo Foo
+	_ = server.Register(&foo)
+	server.Use(func(next Invoker) Invoker {
+		return func(serviceMethod string, args, reply interface{}) error {
+			return next(serviceMethod, &args, reply)
+		}
+	})
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+
+	client, err := Dial("tcp", l.Addr().String())
+	_assert(err == nil, "dial error: %v", err)
+	defer func() { _ = client.Close() }()
+	var reply int
+	err = client.Call(context.Background(), "Foo.Sum", Args{Num1: 1, Num2: 2}, &reply)
+	_assert(err != nil && strings.Contains(err.Error(), "expects (geerpc.Args, *int)"), "expect type mismatch error, got %v", err)
+}
+
+func TestServer_Services(t *testing.T) {
+	server := NewServer()
+	var foo Foo
+	var b Bar
+	_ = server.Register(&foo)
+	_ = server.Register(&b)
+	expect := map[string][]string{
+		"Foo": {"Sum"},
+		"Bar": {"Double", "Panic", "Timeout"},
+	}
+	services := server.Services()
+	_assert(reflect.DeepEqual(services, expect), "unexpected services: %v", services)
+}
+
+func TestServer_AllowCodecs(t *testing.T) {
+	server := NewServer()
+	var foo Foo
+	_ = server.Register(&foo)
+	server.AllowCodecs(codec.GobType)
+	l, _ := net.Listen("tcp", ":0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+
+	client, err := Dial("tcp", l.Addr().String())
+	_assert(err == nil, "failed to dial: %v", err)
+	var reply int
+	err = client.Call(context.Background(), "Foo.Sum", Args{Num1: 1, Num2: 2}, &reply)
+	_assert(err == nil && reply == 3, "expect gob to be allowed: %v", err)
+	_ = client.Close()
+
+	// 只允许其他编解码类型时，gob 客户端的每次调用都得到明确的错误
+	jsonOnly := NewServer()
+	_ = jsonOnly.Register(&foo)
+	jsonOnly.AllowCodecs(codec.JsonType)
+	l2, _ := net.Listen("tcp", ":0")
+	defer func() { _ = l2.Close() }()
+	go jsonOnly.Accept(l2)
+	client, err = Dial("tcp", l2.Addr().String())
+	_assert(err == nil, "failed to dial: %v", err)
+	defer func() { _ = client.Close() }()
+	for i := 0; i < 2; i++ {
+		err = client.Call(context.Background(), "Foo.Sum", Args{Num1: 1, Num2: 2}, &reply)
+		_assert(err != nil && strings.Contains(err.Error(), "codec type application/gob is not allowed"), "expect gob rejected, got %v", err)
+	}
+}
+
+// Counter 是持有状态的服务，每次调用都修改注册时传入的实例
+type Counter struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (c *Counter) Incr(delta int, reply *int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n += delta
+	*reply = c.n
+	return nil
+}
+
+func TestServer_RegisterName(t *testing.T) {
+	server := NewServer()
+	a, b := &Counter{}, &Counter{n: 100}
+	_assert(server.RegisterName("CounterA", a) == nil, "failed to register CounterA")
+	_assert(server.RegisterName("CounterB", b) == nil, "failed to register CounterB")
+	_assert(server.RegisterName("CounterA", &Counter{}) != nil, "expect duplicate name rejected")
+	_assert(server.RegisterName("Bad.Name", &Counter{}) != nil, "expect invalid name rejected")
+
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+	client, err := Dial("tcp", l.Addr().String())
+	_assert(err == nil, "failed to dial: %v", err)
+	defer func() { _ = client.Close() }()
+
+	var reply int
+	for i := 0; i < 3; i++ {
+		_ = client.Call(context.Background(), "CounterA.Incr", 1, &reply)
+	}
+	_assert(reply == 3, "expect CounterA at 3, got %d", reply)
+	_ = client.Call(context.Background(), "CounterB.Incr", 5, &reply)
+	_assert(reply == 105, "expect CounterB at 105, got %d", reply)
+	_assert(a.n == 3 && b.n == 105, "expect calls to mutate registered instances, got %d %d", a.n, b.n)
+}
+
+// counter 未导出的类型，只能通过 RegisterName 以显式名字注册
+type counter struct{ n int }
+
+func (c *counter) Incr(delta int, reply *int) error {
+	c.n += delta
+	*reply = c.n
+	return nil
+}
+
+func TestServer_RegisterNameUnexported(t *testing.T) {
+	server := NewServer()
+	_assert(server.RegisterName("Counter", &counter{n: 10}) == nil, "failed to register unexported type by name")
+	_assert(reflect.DeepEqual(server.Services()["Counter"], []string{"Incr"}), "unexpected methods %v", server.Services())
+
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+	client, err := Dial("tcp", l.Addr().String())
+	_assert(err == nil, "failed to dial: %v", err)
+	defer func() { _ = client.Close() }()
+	var reply int
+	err = client.Call(context.Background(), "Counter.Incr", 1, &reply)
+	_assert(err == nil && reply == 11, "expect 11, got %d %v", reply, err)
+}
+
+type Timed int
+
+func (t Timed) Fast(d time.Duration, reply *int) error {
+	time.Sleep(d)
+	return nil
+}
+
+func (t Timed) Slow(d time.Duration, reply *int) error {
+	time.Sleep(d)
+	return nil
+}
+
+func TestServer_RegisterWithTimeouts(t *testing.T) {
+	server := NewServer()
+	var timed Timed
+	_assert(server.RegisterWithTimeouts(&timed, map[string]time.Duration{"Nope": time.Second}) != nil,
+		"expect unknown method rejected")
+	_assert(server.RegisterWithTimeouts(&timed, map[string]time.Duration{
+		"Fast": time.Millisecond * 20,
+		"Slow": time.Second,
+	}) == nil, "failed to register with timeouts")
+
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer func() { _ = l.Close() }()
+	// 服务端默认超时 100ms，介于两个方法级超时之间
+	server.HandleTimeout = time.Millisecond * 100
+	go server.Accept(l)
+	client, err := Dial("tcp", l.Addr().String())
+	_assert(err == nil, "failed to dial: %v", err)
+	defer func() { _ = client.Close() }()
+
+	var reply int
+	ctx := context.Background()
+	_assert(client.Call(ctx, "Timed.Fast", time.Duration(0), &reply) == nil, "expect quick Fast call to succeed")
+	err = client.Call(ctx, "Timed.Fast", time.Millisecond*60, &reply)
+	_assert(err != nil && strings.Contains(err.Error(), "handle timeout"), "expect Fast to hit its own 20ms timeout, got %v", err)
+	err = client.Call(ctx, "Timed.Slow", time.Millisecond*200, &reply)
+	_assert(err == nil, "expect Slow to outlive the server default timeout, got %v", err)
+
+	// 客户端要求的超时更短时以客户端为准
+	capped, err := Dial("tcp", l.Addr().String(), &Option{HandleTimeout: time.Millisecond * 50})
+	_assert(err == nil, "failed to dial: %v", err)
+	defer func() { _ = capped.Close() }()
+	err = capped.Call(ctx, "Timed.Slow", time.Millisecond*200, &reply)
+	_assert(err != nil && strings.Contains(err.Error(), "within 50ms"), "expect Slow capped by the client, got %v", err)
+}
+
+func TestServer_HandleTimeoutOwnedByServer(t *testing.T) {
+	server := NewServer()
+	server.HandleTimeout = time.Millisecond * 50
+	var timed Timed
+	_assert(server.Register(&timed) == nil, "failed to register")
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+
+	ctx := context.Background()
+	var reply int
+	for _, requested := range []time.Duration{0, time.Hour} { // 客户端不能取消或放宽服务端的超时
+		client, err := Dial("tcp", l.Addr().String(), &Option{HandleTimeout: requested})
+		_assert(err == nil, "failed to dial: %v", err)
+		err = client.Call(ctx, "Timed.Slow", time.Millisecond*200, &reply)
+		_assert(err != nil && strings.Contains(err.Error(), "within 50ms"), "requested %s: expect server timeout, got %v", requested, err)
+		_ = client.Close()
+	}
+}
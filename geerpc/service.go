@@ -1,10 +1,13 @@
 ﻿package geerpc
 
 import (
+	"fmt"
 	"go/ast"
 	"log"
 	"reflect"
+	"runtime"
 	"sync/atomic"
+	"time"
 )
 
 // methodType 描述了一个 RPC 方法的完整元数据
@@ -13,6 +16,7 @@ type methodType struct {
 	ArgType   reflect.Type   // 第 2 个入参的类型（请求结构体）
 	ReplyType reflect.Type   // 第 3 个入参的类型（响应结构体）
 	numCalls  uint64         // 被调用的总次数（原子计数，线程安全）
+	timeout   time.Duration  // 方法级处理超时，>0 时优先于 Server.HandleTimeout
 }
 
 // service 描述了一个 RPC 服务（即一个对象）的全部信息
@@ -62,25 +66,38 @@ func (m *methodType) newReplyv() reflect.Value {
 // newService 使用用户提供的接收者对象构造一个 *service
 // rcvr代表一个实现了 RPC 服务的对象，必须是指针类型，否则无法获取其方法
 func newService(rcvr interface{}) *service {
+	s, err := newNamedService(rcvr, "")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return s
+}
+
+// newNamedService 以指定的服务名构造 *service，name 为空时使用接收者类型的名字。
+// 显式指定名字时不要求接收者类型导出，校验失败返回错误而不是退出进程
+func newNamedService(rcvr interface{}, name string) (*service, error) {
 	s := new(service)
 
 	// 保存接收者对象的反射值
 	s.rcvr = reflect.ValueOf(rcvr)
 
-	// 服务名 = 接收者类型的名字（去掉指针层级）
-	s.name = reflect.Indirect(s.rcvr).Type().Name()
-
 	// 记录类型信息
 	s.typ = reflect.TypeOf(rcvr)
 
-	// 类型名必须导出，否则无法被包外调用
-	if !ast.IsExported(s.name) {
-		log.Fatalf("rpc server: %s is not a valid service name", s.name)
+	if name != "" {
+		s.name = name
+	} else {
+		// 服务名 = 接收者类型的名字（去掉指针层级）
+		s.name = reflect.Indirect(s.rcvr).Type().Name()
+		// 类型名必须导出，否则无法被包外调用
+		if !ast.IsExported(s.name) {
+			return nil, fmt.Errorf("rpc server: %s is not a valid service name", s.name)
+		}
 	}
 
-	// 扫描并注册所有符合规范的方法
+	// 扫描并注册所有符合规范的方法（跳过方法的日志使用注册的服务名）
 	s.registerMethods()
-	return s
+	return s, nil
 }
 
 // registerMethods 遍历接收者类型的所有方法，将符合 RPC 规范的方法注册到 service.method
@@ -107,6 +124,11 @@ func (s *service) registerMethods() {
 		if !isExportedOrBuiltinType(argType) || !isExportedOrBuiltinType(replyType) {
 			continue
 		}
+		// reply 必须是指针，服务方法才能写回结果（newReplyv 也依赖这一点）
+		if replyType.Kind() != reflect.Ptr {
+			log.Printf("rpc server: %s.%s reply type %s is not a pointer, skipped", s.name, method.Name, replyType)
+			continue
+		}
 
 		// 方法通过所有检查，加入映射
 		s.method[method.Name] = &methodType{
@@ -125,10 +147,20 @@ func isExportedOrBuiltinType(t reflect.Type) bool {
 
 // call 真正执行一次 RPC 方法调用
 // argv、replyv 已经通过 newArgv/newReplyv 构造并解码完成
-func (s *service) call(m *methodType, argv, replyv reflect.Value) error {
+func (s *service) call(m *methodType, argv, replyv reflect.Value) (err error) {
 	// 原子增加调用次数
 	atomic.AddUint64(&m.numCalls, 1)
 
+	// 业务方法 panic 时转换为错误返回给客户端，避免整个服务端进程崩溃
+	defer func() {
+		if r := recover(); r != nil {
+			buf := make([]byte, 4096)
+			buf = buf[:runtime.Stack(buf, false)]
+			log.Printf("rpc server: %s.%s panic: %v\n%s", s.name, m.method.Name, r, buf)
+			err = fmt.Errorf("rpc server: %s.%s panic: %v", s.name, m.method.Name, r)
+		}
+	}()
+
 	// 取出方法对应的函数值
 	f := m.method.Func
 
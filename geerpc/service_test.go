@@ -80,3 +80,29 @@ func TestMethodType_Call(t *testing.T) {
 		mType.NumCalls() == 1,
 		"failed to call Foo.Sum")
 }
+
+// Mixed 同时包含 reply 为值类型（不合法）与指针类型（合法）的方法
+type Mixed int
+
+func (m Mixed) BadReply(args int, reply int) error {
+	return nil
+}
+
+func (m Mixed) Good(args *Args, reply *int) error {
+	*reply = args.Num1 + args.Num2
+	return nil
+}
+
+// TestNewService_ReplyNotPointer 测试 reply 不是指针的方法不会被注册
+func TestNewService_ReplyNotPointer(t *testing.T) {
+	var m Mixed
+	s := newService(&m)
+	_assert(s.method["BadReply"] == nil, "method with non-pointer reply shouldn't be registered")
+	mType := s.method["Good"]
+	_assert(mType != nil, "method with pointer reply should be registered")
+
+	argv, replyv := mType.newArgv(), mType.newReplyv()
+	argv.Elem().Set(reflect.ValueOf(Args{Num1: 1, Num2: 2}))
+	err := s.call(mType, argv, replyv)
+	_assert(err == nil && *replyv.Interface().(*int) == 3, "failed to call method with pointer args")
+}
@@ -9,12 +9,18 @@ import (
 )
 
 const (
-	RandomSelect     SelectMode = iota // select randomly
-	RoundRobinSelect                   // select in a round-robin way
+	RandomSelect       SelectMode = iota // select randomly
+	RoundRobinSelect                     // select in a round-robin way
+	LeastPendingSelect                   // select the server with the fewest in-flight calls, handled by XClient
 )
 
 var _ Discovery = (*MultiServerDiscovery)(nil)
 
+var (
+	ErrNoAvailableServers = errors.New("rpc discovery: no available servers")
+	ErrInvalidSelectMode  = errors.New("rpc discovery: invalid select mode")
+)
+
 type SelectMode int
 
 type Discovery interface {
@@ -24,8 +30,10 @@ type Discovery interface {
 	GetAll() ([]string, error)
 }
 
+// MultiServerDiscovery 基于手动维护的地址列表做负载均衡，
+// 随机数源与轮询下标均为实例私有，由 mu 保护
 type MultiServerDiscovery struct {
-	r       *rand.Rand
+	r       *rand.Rand // 每个实例独立播种，避免依赖全局随机源
 	mu      sync.RWMutex
 	servers []string
 	index   int
@@ -33,7 +41,7 @@ type MultiServerDiscovery struct {
 
 func NewMultiServerDiscovery(servers []string) *MultiServerDiscovery {
 	d := &MultiServerDiscovery{
-		servers: servers,
+		servers: validServers(servers),
 		r:       rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 	d.index = d.r.Intn(math.MaxInt32 - 1)
@@ -47,16 +55,27 @@ func (d *MultiServerDiscovery) Refresh() error {
 func (d *MultiServerDiscovery) Update(servers []string) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	d.servers = servers
+	d.servers = validServers(servers)
 	return nil
 }
 
+// validServers 复制地址列表并剔除空地址，防止返回看似合法的空串
+func validServers(servers []string) []string {
+	valid := make([]string, 0, len(servers))
+	for _, s := range servers {
+		if s != "" {
+			valid = append(valid, s)
+		}
+	}
+	return valid
+}
+
 func (d *MultiServerDiscovery) Get(mode SelectMode) (string, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	n := len(d.servers)
 	if n == 0 {
-		return "", errors.New("rpc discovery: no available servers")
+		return "", ErrNoAvailableServers
 	}
 	switch mode {
 	case RandomSelect:
@@ -66,7 +85,7 @@ func (d *MultiServerDiscovery) Get(mode SelectMode) (string, error) {
 		d.index = (d.index + 1) % n
 		return s, nil
 	default:
-		return "", errors.New("rpc discovery: invalid select mode")
+		return "", ErrInvalidSelectMode
 	}
 }
 
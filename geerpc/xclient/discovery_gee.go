@@ -38,7 +38,7 @@ func NewGeeRegistryDiscovery(registry string, timeout time.Duration) *GeeRegistr
 func (d *GeeRegistryDiscovery) Update(servers []string) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	d.servers = servers
+	d.servers = validServers(servers)
 	d.lastUpdate = time.Now()
 	return nil
 }
@@ -53,7 +53,27 @@ func (d *GeeRegistryDiscovery) Refresh() error {
 	if d.lastUpdate.Add(d.timeout).After(time.Now()) {
 		return nil
 	}
+	return d.pull()
+}
+
+// ForceRefresh 忽略缓存是否过期，立即从注册中心拉取节点列表。
+// 适用于测试或故障切换时需要马上感知节点变化的场景。
+func (d *GeeRegistryDiscovery) ForceRefresh() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.pull()
+}
+
+// Reset 清空本地缓存的节点列表，下一次 Get/GetAll 会重新向注册中心拉取。
+func (d *GeeRegistryDiscovery) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.servers = nil
+	d.lastUpdate = time.Time{}
+}
 
+// pull 向注册中心请求节点列表并写入缓存，调用方需持有 d.mu。
+func (d *GeeRegistryDiscovery) pull() error {
 	log.Println("rpc registry: refresh servers from registry", d.registry)
 
 	// 1. 发起 HTTP 请求
@@ -0,0 +1,88 @@
+﻿package xclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nukecoke1828/7daysProgram/geerpc/registry"
+)
+
+func TestMultiServerDiscovery_Get(t *testing.T) {
+	servers := []string{"tcp@a", "tcp@b", "tcp@c"}
+	d := NewMultiServerDiscovery(servers)
+	t.Run("random", func(t *testing.T) {
+		seen := make(map[string]bool)
+		for i := 0; i < 300; i++ {
+			s, err := d.Get(RandomSelect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			seen[s] = true
+		}
+		if len(seen) != len(servers) {
+			t.Fatal("random select should cover all servers, got", seen)
+		}
+	})
+	t.Run("round robin", func(t *testing.T) {
+		first, _ := d.Get(RoundRobinSelect)
+		seen := map[string]bool{first: true}
+		for i := 1; i < len(servers); i++ {
+			s, _ := d.Get(RoundRobinSelect)
+			seen[s] = true
+		}
+		if next, _ := d.Get(RoundRobinSelect); len(seen) != len(servers) || next != first {
+			t.Fatal("round robin should visit each server once per cycle, got", seen)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		empty := NewMultiServerDiscovery([]string{""})
+		if s, err := empty.Get(RandomSelect); !errors.Is(err, ErrNoAvailableServers) || s != "" {
+			t.Fatal("expect no available servers error, got", s, err)
+		}
+		if _, err := d.Get(SelectMode(-1)); !errors.Is(err, ErrInvalidSelectMode) {
+			t.Fatal("expect invalid select mode error, got", err)
+		}
+	})
+}
+
+// register 模拟一次心跳，把 addr 注册到注册中心
+func register(t *testing.T, registryURL, addr string) {
+	req, _ := http.NewRequest("POST", registryURL, nil)
+	req.Header.Set("X-Geerpc-Server", addr)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestGeeRegistryDiscovery_ForceRefresh(t *testing.T) {
+	ts := httptest.NewServer(registry.New(0))
+	defer ts.Close()
+	register(t, ts.URL, "tcp@a")
+
+	d := NewGeeRegistryDiscovery(ts.URL, time.Hour)
+	if servers, err := d.GetAll(); err != nil || !reflect.DeepEqual(servers, []string{"tcp@a"}) {
+		t.Fatal("failed to get servers from registry, got", servers, err)
+	}
+	register(t, ts.URL, "tcp@b")
+	if servers, _ := d.GetAll(); !reflect.DeepEqual(servers, []string{"tcp@a"}) {
+		t.Fatal("servers should be cached before timeout, got", servers)
+	}
+	if err := d.ForceRefresh(); err != nil {
+		t.Fatal(err)
+	}
+	if servers, _ := d.GetAll(); !reflect.DeepEqual(servers, []string{"tcp@a", "tcp@b"}) {
+		t.Fatal("failed to force refresh, got", servers)
+	}
+
+	register(t, ts.URL, "tcp@c")
+	d.Reset()
+	if servers, _ := d.GetAll(); !reflect.DeepEqual(servers, []string{"tcp@a", "tcp@b", "tcp@c"}) {
+		t.Fatal("servers should be pulled again after reset, got", servers)
+	}
+}
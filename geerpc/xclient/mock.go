@@ -0,0 +1,69 @@
+﻿package xclient
+
+import (
+	"sync"
+)
+
+// 编译期断言：确保 *MockDiscovery 实现了 Discovery 接口
+var _ Discovery = (*MockDiscovery)(nil)
+
+// MockDiscovery 是供测试使用的内存 Discovery：
+//   - Get 忽略负载均衡策略，按列表顺序依次返回地址，结果完全可预测。
+//   - Err 非 nil 时，所有方法都返回该错误，便于模拟服务发现故障。
+type MockDiscovery struct {
+	mu      sync.Mutex
+	servers []string
+	index   int   // 下一次 Get 返回的下标
+	Err     error // 注入的错误
+	Gets    int   // Get 被调用的次数
+}
+
+// NewMockDiscovery 创建一个基于固定地址列表的 MockDiscovery
+func NewMockDiscovery(servers ...string) *MockDiscovery {
+	return &MockDiscovery{servers: append([]string(nil), servers...)}
+}
+
+// Refresh 不做任何事，仅返回注入的错误
+func (d *MockDiscovery) Refresh() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.Err
+}
+
+// Update 替换地址列表，并从头开始返回
+func (d *MockDiscovery) Update(servers []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.Err != nil {
+		return d.Err
+	}
+	d.servers = append([]string(nil), servers...)
+	d.index = 0
+	return nil
+}
+
+// Get 按顺序循环返回地址，列表为空时返回 ErrNoAvailableServers
+func (d *MockDiscovery) Get(mode SelectMode) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Gets++
+	if d.Err != nil {
+		return "", d.Err
+	}
+	if len(d.servers) == 0 {
+		return "", ErrNoAvailableServers
+	}
+	s := d.servers[d.index%len(d.servers)]
+	d.index++
+	return s, nil
+}
+
+// GetAll 返回地址列表的副本
+func (d *MockDiscovery) GetAll() ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.Err != nil {
+		return nil, d.Err
+	}
+	return append([]string(nil), d.servers...), nil
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"reflect"
 	"sync"
+	"time"
 
 	. "github.com/nukecoke1828/7daysProgram/geerpc"
 )
@@ -23,6 +24,8 @@ type XClient struct {
 	opt     *Option            // 全局 RPC 配置（编解码、超时等）
 	mu      sync.Mutex         // 保护 clients 并发读写
 	clients map[string]*Client // 地址 -> *Client 的本地连接池
+	stop    chan struct{}      // 关闭时通知健康检查 goroutine 退出
+	once    sync.Once          // 保证 stop 只关闭一次
 }
 
 // NewXClient 创建一个新的 XClient 实例。
@@ -37,12 +40,69 @@ func NewXClient(d Discovery, mode SelectMode, opt *Option) *XClient {
 		mode:    mode,
 		opt:     opt,
 		clients: make(map[string]*Client),
+		stop:    make(chan struct{}),
 	}
 }
 
+// NewXClientWithHealthCheck 创建 XClient，并在后台每隔 interval 对已缓存的连接执行 Health.Ping，
+// 探测失败的连接会被关闭并移出缓存。interval <= 0 时不启动健康检查，等同于 NewXClient。
+func NewXClientWithHealthCheck(d Discovery, mode SelectMode, opt *Option, interval time.Duration) *XClient {
+	xc := NewXClient(d, mode, opt)
+	if interval > 0 {
+		go xc.probeLoop(interval)
+	}
+	return xc
+}
+
+// probeLoop 周期性探测缓存的连接，直到 XClient 被关闭
+func (xc *XClient) probeLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-xc.stop:
+			return
+		case <-ticker.C:
+			xc.probe(interval)
+		}
+	}
+}
+
+// probe 并发 Ping 所有缓存的连接，剔除失败的连接。
+// Ping 期间不持有锁，剔除时确认缓存中仍是同一个连接，避免误删重新建立的连接。
+func (xc *XClient) probe(timeout time.Duration) {
+	xc.mu.Lock()
+	clients := make(map[string]*Client, len(xc.clients))
+	for rpcAddr, client := range xc.clients {
+		clients[rpcAddr] = client
+	}
+	xc.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for rpcAddr, client := range clients {
+		wg.Add(1)
+		go func(rpcAddr string, client *Client) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := client.Ping(ctx); err == nil {
+				return
+			}
+			xc.mu.Lock()
+			defer xc.mu.Unlock()
+			if xc.clients[rpcAddr] == client {
+				_ = client.Close()
+				delete(xc.clients, rpcAddr)
+			}
+		}(rpcAddr, client)
+	}
+	wg.Wait()
+}
+
 // Close 关闭并清理所有已缓存的 RPC 连接。
 // 实现 io.Closer 接口，可在程序退出时统一调用。
 func (xc *XClient) Close() error {
+	xc.once.Do(func() { close(xc.stop) }) // 停止健康检查
 	xc.mu.Lock()
 	defer xc.mu.Unlock()
 	for key, client := range xc.clients {
@@ -94,7 +154,13 @@ func (xc *XClient) call(rpcAddr string, ctx context.Context, serviceMethod strin
 // 对用户暴露的“单点调用”入口。
 func (xc *XClient) Call(ctx context.Context, serviceMethod string, args, reply interface{}) error {
 	// 1. 使用 Discovery 和负载均衡策略选出一个地址
-	rpcAddr, err := xc.d.Get(xc.mode)
+	var rpcAddr string
+	var err error
+	if xc.mode == LeastPendingSelect {
+		rpcAddr, err = xc.leastPending() // Discovery 不掌握连接负载，由 XClient 自行挑选
+	} else {
+		rpcAddr, err = xc.d.Get(xc.mode)
+	}
 	if err != nil {
 		return err
 	}
@@ -102,6 +168,38 @@ func (xc *XClient) Call(ctx context.Context, serviceMethod string, args, reply i
 	return xc.call(rpcAddr, ctx, serviceMethod, args, reply)
 }
 
+// CallTimeout 是 Call 的便捷封装：在 timeout 内未完成则返回超时错误。
+func (xc *XClient) CallTimeout(timeout time.Duration, serviceMethod string, args, reply interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return xc.Call(ctx, serviceMethod, args, reply)
+}
+
+// leastPending 选出在途调用最少的节点。
+// 尚未建连（或连接已不可用）的节点视为 0 个在途调用；数量相同时取靠前的节点。
+func (xc *XClient) leastPending() (string, error) {
+	servers, err := xc.d.GetAll()
+	if err != nil {
+		return "", err
+	}
+	if len(servers) == 0 {
+		return "", ErrNoAvailableServers
+	}
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	best, least := "", -1
+	for _, rpcAddr := range servers {
+		pending := 0
+		if client, ok := xc.clients[rpcAddr]; ok && client.IsAvailable() {
+			pending = client.Pending()
+		}
+		if least == -1 || pending < least {
+			best, least = rpcAddr, pending
+		}
+	}
+	return best, nil
+}
+
 // Broadcast 并发地向所有服务节点发起同一 RPC 调用。
 // 规则：
 //   - 任意节点返回成功即把结果写入 reply，后续成功不再覆盖。
@@ -163,3 +261,46 @@ func (xc *XClient) Broadcast(ctx context.Context, serviceMethod string, args, re
 	wg.Wait()
 	return e // 返回第一个遇到的错误（可能为 nil）
 }
+
+// BroadcastResult 是 BroadcastChan 中单个节点的调用结果。
+type BroadcastResult struct {
+	Addr  string      // 节点地址
+	Reply interface{} // 该节点独享的 reply 实例（与原型 reply 同类型的新指针）
+	Err   error       // 调用错误
+}
+
+// BroadcastChan 并发地向所有服务节点发起同一 RPC 调用，每个节点完成后立即把结果发到返回的 channel。
+// 规则：
+//   - reply 仅作为类型原型，不会被写入；每个结果都携带新建的 reply 实例。reply==nil 时结果中的 Reply 也为 nil。
+//   - 取消 ctx 会中止尚未完成的调用，这些节点的结果以 ctx 错误返回。
+//   - 所有节点都返回后 channel 被关闭；channel 有足够缓冲，调用方提前停止读取也不会阻塞后台 goroutine。
+//   - 获取节点列表失败时，channel 中只有一个 Addr 为空、携带错误的结果。
+func (xc *XClient) BroadcastChan(ctx context.Context, serviceMethod string, args, reply interface{}) <-chan BroadcastResult {
+	servers, err := xc.d.GetAll()
+	if err != nil {
+		ch := make(chan BroadcastResult, 1)
+		ch <- BroadcastResult{Err: err}
+		close(ch)
+		return ch
+	}
+
+	ch := make(chan BroadcastResult, len(servers))
+	var wg sync.WaitGroup
+	for _, rpcAddr := range servers {
+		wg.Add(1)
+		go func(rpcAddr string) {
+			defer wg.Done()
+			var newReply interface{}
+			if reply != nil {
+				newReply = reflect.New(reflect.ValueOf(reply).Elem().Type()).Interface()
+			}
+			err := xc.call(rpcAddr, ctx, serviceMethod, args, newReply)
+			ch <- BroadcastResult{Addr: rpcAddr, Reply: newReply, Err: err}
+		}(rpcAddr)
+	}
+	go func() {
+		wg.Wait()
+		close(ch)
+	}()
+	return ch
+}
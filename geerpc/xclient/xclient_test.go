@@ -0,0 +1,208 @@
+﻿package xclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nukecoke1828/7daysProgram/geerpc"
+)
+
+// Node 返回所在节点的名字，Sleep 用于模拟耗时调用
+type Node struct {
+	name string
+}
+
+func (n *Node) Name(args int, reply *string) error {
+	*reply = n.name
+	return nil
+}
+
+func (n *Node) Sleep(args int, reply *string) error {
+	time.Sleep(time.Duration(args) * time.Millisecond)
+	*reply = n.name
+	return nil
+}
+
+func startNode(t *testing.T, name string) string {
+	server := geerpc.NewServer()
+	if err := server.Register(&Node{name: name}); err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Accept(l)
+	return "tcp@" + l.Addr().String()
+}
+
+func TestXClient_LeastPendingSelect(t *testing.T) {
+	busy, idle := startNode(t, "busy"), startNode(t, "idle")
+	xc := NewXClient(NewMultiServerDiscovery([]string{busy, idle}), LeastPendingSelect, nil)
+	defer func() { _ = xc.Close() }()
+
+	client, err := xc.dial(busy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		var reply string
+		client.Go("Node.Sleep", 500, &reply, nil) // 让 busy 节点积压在途调用
+	}
+	if pending := client.Pending(); pending != 3 {
+		t.Fatal("expect 3 pending calls, got", pending)
+	}
+	for i := 0; i < 3; i++ {
+		var reply string
+		if err := xc.Call(context.Background(), "Node.Name", 0, &reply); err != nil || reply != "idle" {
+			t.Fatal("expect the less loaded node, got", reply, err)
+		}
+	}
+}
+
+func TestXClient_CallTimeout(t *testing.T) {
+	xc := NewXClient(NewMultiServerDiscovery([]string{startNode(t, "a")}), RandomSelect, nil)
+	defer func() { _ = xc.Close() }()
+
+	var reply string
+	if err := xc.CallTimeout(50*time.Millisecond, "Node.Sleep", 500, &reply); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("expect a timeout error, got", err)
+	}
+	if err := xc.CallTimeout(time.Second, "Node.Name", 0, &reply); err != nil || reply != "a" {
+		t.Fatal("expect a successful call, got", reply, err)
+	}
+}
+
+func TestXClient_BroadcastChan(t *testing.T) {
+	addrs := []string{startNode(t, "a"), startNode(t, "b"), startNode(t, "c")}
+	xc := NewXClient(NewMultiServerDiscovery(addrs), RandomSelect, nil)
+	defer func() { _ = xc.Close() }()
+
+	t.Run("all nodes", func(t *testing.T) {
+		got := make(map[string]string)
+		for res := range xc.BroadcastChan(context.Background(), "Node.Name", 0, new(string)) {
+			if res.Err != nil {
+				t.Fatal(res.Addr, res.Err)
+			}
+			got[res.Addr] = *res.Reply.(*string)
+		}
+		want := map[string]string{addrs[0]: "a", addrs[1]: "b", addrs[2]: "c"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("expect %v, got %v", want, got)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		n := 0
+		for res := range xc.BroadcastChan(ctx, "Node.Sleep", 2000, new(string)) {
+			if res.Err == nil {
+				t.Fatal("expect a ctx error from", res.Addr)
+			}
+			n++
+		}
+		if n != 3 || time.Since(start) > time.Second {
+			t.Fatalf("expect 3 cancelled results promptly, got %d in %s", n, time.Since(start))
+		}
+	})
+}
+
+// trackListener 记录接受的连接，便于模拟服务端宕机
+type trackListener struct {
+	net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (l *trackListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.mu.Lock()
+		l.conns = append(l.conns, conn)
+		l.mu.Unlock()
+	}
+	return conn, err
+}
+
+// kill 关闭监听器以及所有已接受的连接
+func (l *trackListener) kill() {
+	_ = l.Listener.Close()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, conn := range l.conns {
+		_ = conn.Close()
+	}
+}
+
+func TestXClient_HealthCheck(t *testing.T) {
+	server := geerpc.NewServer()
+	_ = server.Register(&Node{name: "dying"})
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &trackListener{Listener: inner}
+	go server.Accept(l)
+	addr := "tcp@" + inner.Addr().String()
+
+	xc := NewXClientWithHealthCheck(NewMultiServerDiscovery([]string{addr}), RandomSelect, nil, 50*time.Millisecond)
+	defer func() { _ = xc.Close() }()
+	var reply string
+	if err := xc.Call(context.Background(), "Node.Name", 0, &reply); err != nil {
+		t.Fatal(err)
+	}
+
+	cached := func() bool {
+		xc.mu.Lock()
+		defer xc.mu.Unlock()
+		_, ok := xc.clients[addr]
+		return ok
+	}
+	time.Sleep(120 * time.Millisecond) // 健康的连接不应被剔除
+	if !cached() {
+		t.Fatal("healthy client should stay cached")
+	}
+
+	l.kill()
+	deadline := time.Now().Add(time.Second)
+	for cached() {
+		if time.Now().After(deadline) {
+			t.Fatal("dead client should be evicted by the health check")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestXClient_MockDiscovery(t *testing.T) {
+	t.Run("no servers", func(t *testing.T) {
+		xc := NewXClient(NewMockDiscovery(), RandomSelect, nil)
+		defer func() { _ = xc.Close() }()
+		var reply string
+		if err := xc.Call(context.Background(), "Node.Name", 0, &reply); !errors.Is(err, ErrNoAvailableServers) {
+			t.Fatal("expect ErrNoAvailableServers, got", err)
+		}
+	})
+	t.Run("deterministic order", func(t *testing.T) {
+		d := NewMockDiscovery(startNode(t, "a"), startNode(t, "b"))
+		xc := NewXClient(d, RandomSelect, nil)
+		defer func() { _ = xc.Close() }()
+		var got []string
+		for i := 0; i < 4; i++ {
+			var reply string
+			if err := xc.Call(context.Background(), "Node.Name", 0, &reply); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, reply)
+		}
+		if !reflect.DeepEqual(got, []string{"a", "b", "a", "b"}) || d.Gets != 4 {
+			t.Fatal("expect calls in discovery order, got", got, d.Gets)
+		}
+	})
+}